@@ -0,0 +1,117 @@
+package redisb
+
+import (
+	"io"
+	"math/big"
+)
+
+// Args is a command and its arguments, of any type a Writer accepts:
+// strings, []byte, integers, floats, bools, time.Duration, time.Time,
+// encoding.BinaryMarshaler and fmt.Stringer. Arguments of any other type
+// fail the call with a ProtocolError before anything is written.
+type Args []interface{}
+
+// Add returns a with values appended.
+func (a Args) Add(values ...interface{}) Args {
+	return append(a, values...)
+}
+
+func RawArgs(rw io.ReadWriter, args Args) (interface{}, error) {
+	return do(rw, args)
+}
+
+func Int64Args(rw io.ReadWriter, args Args) (int64, error) {
+	i, err := do(rw, args)
+	if err != nil {
+		return 0, err
+	}
+	return toInt64(i)
+}
+
+func BoolArgs(rw io.ReadWriter, args Args) (bool, error) {
+	i, err := do(rw, args)
+	if err != nil {
+		return false, err
+	}
+	return toBool(i)
+}
+
+func StringArgs(rw io.ReadWriter, args Args) (string, error) {
+	i, err := do(rw, args)
+	if err != nil {
+		return "", err
+	}
+	return toString(i)
+}
+
+func Float64Args(rw io.ReadWriter, args Args) (float64, error) {
+	i, err := do(rw, args)
+	if err != nil {
+		return 0, err
+	}
+	return toFloat64(i)
+}
+
+func BigIntArgs(rw io.ReadWriter, args Args) (*big.Int, error) {
+	i, err := do(rw, args)
+	if err != nil {
+		return nil, err
+	}
+	return toBigInt(i)
+}
+
+func MapArgs(rw io.ReadWriter, args Args) (map[string]interface{}, error) {
+	i, err := do(rw, args)
+	if err != nil {
+		return nil, err
+	}
+	return toMap(i)
+}
+
+func ArrayArgs(rw io.ReadWriter, args Args) ([]interface{}, error) {
+	i, err := do(rw, args)
+	if err != nil {
+		return nil, err
+	}
+	return toArray(i)
+}
+
+func BoolsArgs(rw io.ReadWriter, args Args) ([]bool, error) {
+	i, err := do(rw, args)
+	if err != nil {
+		return nil, err
+	}
+	return toBools(i)
+}
+
+func Int64sArgs(rw io.ReadWriter, args Args) ([]int64, error) {
+	i, err := do(rw, args)
+	if err != nil {
+		return nil, err
+	}
+	return toInt64s(i)
+}
+
+func StringsArgs(rw io.ReadWriter, args Args) ([]string, error) {
+	i, err := do(rw, args)
+	if err != nil {
+		return nil, err
+	}
+	return toStrings(i)
+}
+
+func BytesArgs(rw io.ReadWriter, args Args) ([]byte, error) {
+	i, err := doBytes(rw, args)
+	if err != nil {
+		return nil, err
+	}
+	return toBytes(i)
+}
+
+func BytesSliceArgs(rw io.ReadWriter, args Args) ([][]byte, error) {
+	i, err := doBytes(rw, args)
+	if err != nil {
+		return nil, err
+	}
+	return toBytesSlice(i)
+}
@@ -0,0 +1,78 @@
+package redisb
+
+import (
+	"bytes"
+	"errors"
+	"math"
+	"net"
+	"testing"
+	"time"
+)
+
+type badMarshaler struct{}
+
+func (badMarshaler) MarshalBinary() ([]byte, error) { return nil, errors.New("nope") }
+
+func TestArgsEncoding(t *testing.T) {
+	cases := []struct {
+		in  interface{}
+		out string
+	}{
+		{int8(-3), "-3"},
+		{uint16(7), "7"},
+		{uint64(math.MaxUint64), "18446744073709551615"},
+		{1e6, "1000000"},
+		{float32(0.1), "0.1"},
+		{-2.5, "-2.5"},
+		{math.Inf(1), "inf"},
+		{math.Inf(-1), "-inf"},
+		{true, "1"},
+		{false, "0"},
+		{1500 * time.Millisecond, "1500"},
+		{time.Microsecond, "1"},
+		{time.Duration(0), "0"},
+		{time.Unix(1, 500e6), "1500"},
+		{net.IPv4(10, 0, 0, 1), "10.0.0.1"},
+		{[]byte("b"), "b"},
+	}
+	for _, c := range cases {
+		var b bytes.Buffer
+		if err := NewWriter(&b).WriteCommand(c.in); err != nil {
+			t.Errorf("WriteCommand: %#v: %s", c.in, err)
+			continue
+		}
+		want := Encode([]string{c.out})
+		if b.String() != want {
+			t.Errorf("WriteCommand: %#v: %q - %q", c.in, want, b.String())
+		}
+	}
+	for _, in := range []interface{}{math.NaN(), badMarshaler{}, struct{}{}, nil} {
+		if _, ok := NewWriter(&bytes.Buffer{}).Append("set", in).(ProtocolError); !ok {
+			t.Errorf("Append: %#v: expected ProtocolError", in)
+		}
+	}
+}
+
+func TestArgs(t *testing.T) {
+	var got []string
+	c := fakeServer(t, func(cmd []string) string {
+		got = cmd
+		return ":1\r\n"
+	})
+	ok, err := BoolArgs(c, Args{"pexpire"}.Add("k", 2*time.Second))
+	if err != nil || !ok {
+		t.Fatalf("BoolArgs: %v, %v", ok, err)
+	}
+	if len(got) != 3 || got[2] != "2000" {
+		t.Errorf("BoolArgs sent: %q", got)
+	}
+	if _, err := c.Do("zadd", "z", 1e6, "m"); err != nil {
+		t.Fatal(err)
+	}
+	if got[2] != "1000000" {
+		t.Errorf("Do sent: %q", got)
+	}
+	if _, err := c.Do("set", "k", struct{}{}); err == nil || c.Err() != nil {
+		t.Errorf("Do: expected ProtocolError without breaking Conn, got %v, %v", err, c.Err())
+	}
+}
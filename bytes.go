@@ -0,0 +1,162 @@
+package redisb
+
+import (
+	"io"
+	"math/big"
+	"strconv"
+)
+
+// The B variants of the typed helpers take binary-safe []byte arguments.
+
+func RawB(rw io.ReadWriter, args ...[]byte) (interface{}, error) {
+	return do(rw, args)
+}
+
+func Int64B(rw io.ReadWriter, args ...[]byte) (int64, error) {
+	i, err := do(rw, args)
+	if err != nil {
+		return 0, err
+	}
+	return toInt64(i)
+}
+
+func BoolB(rw io.ReadWriter, args ...[]byte) (bool, error) {
+	i, err := do(rw, args)
+	if err != nil {
+		return false, err
+	}
+	return toBool(i)
+}
+
+func StringB(rw io.ReadWriter, args ...[]byte) (string, error) {
+	i, err := do(rw, args)
+	if err != nil {
+		return "", err
+	}
+	return toString(i)
+}
+
+func Float64B(rw io.ReadWriter, args ...[]byte) (float64, error) {
+	i, err := do(rw, args)
+	if err != nil {
+		return 0, err
+	}
+	return toFloat64(i)
+}
+
+func BigIntB(rw io.ReadWriter, args ...[]byte) (*big.Int, error) {
+	i, err := do(rw, args)
+	if err != nil {
+		return nil, err
+	}
+	return toBigInt(i)
+}
+
+func MapB(rw io.ReadWriter, args ...[]byte) (map[string]interface{}, error) {
+	i, err := do(rw, args)
+	if err != nil {
+		return nil, err
+	}
+	return toMap(i)
+}
+
+func ArrayB(rw io.ReadWriter, args ...[]byte) ([]interface{}, error) {
+	i, err := do(rw, args)
+	if err != nil {
+		return nil, err
+	}
+	return toArray(i)
+}
+
+func BoolsB(rw io.ReadWriter, args ...[]byte) ([]bool, error) {
+	i, err := do(rw, args)
+	if err != nil {
+		return nil, err
+	}
+	return toBools(i)
+}
+
+func Int64sB(rw io.ReadWriter, args ...[]byte) ([]int64, error) {
+	i, err := do(rw, args)
+	if err != nil {
+		return nil, err
+	}
+	return toInt64s(i)
+}
+
+func StringsB(rw io.ReadWriter, args ...[]byte) ([]string, error) {
+	i, err := do(rw, args)
+	if err != nil {
+		return nil, err
+	}
+	return toStrings(i)
+}
+
+// Bytes returns the raw payload of a Bulk String reply. A Null reply is
+// returned as a nil slice.
+func Bytes(rw io.ReadWriter, args ...string) ([]byte, error) {
+	i, err := doBytes(rw, args)
+	if err != nil {
+		return nil, err
+	}
+	return toBytes(i)
+}
+
+func BytesB(rw io.ReadWriter, args ...[]byte) ([]byte, error) {
+	i, err := doBytes(rw, args)
+	if err != nil {
+		return nil, err
+	}
+	return toBytes(i)
+}
+
+func toBytes(i interface{}) ([]byte, error) {
+	switch t := i.(type) {
+	case []byte:
+		return t, nil
+	case string:
+		return []byte(t), nil
+	case int64:
+		return strconv.AppendInt(nil, t, 10), nil
+	case nil:
+		return nil, nil
+	}
+	return nil, newConversionError("Conversion to []byte failed: %#v", i)
+}
+
+// BytesSlice returns the raw payloads of an Array reply, with Null elements
+// as nil slices.
+func BytesSlice(rw io.ReadWriter, args ...string) ([][]byte, error) {
+	i, err := doBytes(rw, args)
+	if err != nil {
+		return nil, err
+	}
+	return toBytesSlice(i)
+}
+
+func BytesSliceB(rw io.ReadWriter, args ...[]byte) ([][]byte, error) {
+	i, err := doBytes(rw, args)
+	if err != nil {
+		return nil, err
+	}
+	return toBytesSlice(i)
+}
+
+func toBytesSlice(i interface{}) ([][]byte, error) {
+	a, ok := i.([]interface{})
+	if !ok {
+		return nil, newConversionError("Conversion to [][]byte failed: %#v", i)
+	}
+	result := [][]byte{}
+	for _, v := range a {
+		if re, ok := v.(RedisError); ok {
+			return nil, re
+		}
+		sv, err := toBytes(v)
+		if err != nil {
+			return nil, newConversionError("Conversion to [][]byte failed: %#v: %s", i, err)
+		}
+		result = append(result, sv)
+	}
+	return result, nil
+}
@@ -0,0 +1,43 @@
+package redisb
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestEncodeBytes(t *testing.T) {
+	got := Encode([][]byte{[]byte("set"), {0, '\r', '\n', 0xff}})
+	want := "*2\r\n$3\r\nset\r\n$4\r\n\x00\r\n\xff\r\n"
+	if got != want {
+		t.Errorf("Encode: %q - %q", want, got)
+	}
+}
+
+func TestBytes(t *testing.T) {
+	payload := []byte{0, '\r', '\n', 0xff}
+	var sent []byte
+	c := fakeServer(t, func(cmd []string) string {
+		switch cmd[0] {
+		case "set":
+			sent = []byte(cmd[2])
+			return "+OK\r\n"
+		case "get":
+			return "$4\r\n" + string(payload) + "\r\n"
+		}
+		return "*2\r\n$4\r\n" + string(payload) + "\r\n$-1\r\n"
+	})
+	if ok, err := BoolB(c, []byte("set"), []byte("k"), payload); err != nil || !ok {
+		t.Fatalf("BoolB: %v, %v", ok, err)
+	}
+	if !bytes.Equal(sent, payload) {
+		t.Errorf("BoolB sent: %q", sent)
+	}
+	b, err := c.Bytes("get", "k")
+	if err != nil || !bytes.Equal(b, payload) {
+		t.Errorf("Bytes: %q, %v", b, err)
+	}
+	bs, err := BytesSliceB(c, []byte("mget"), []byte("k"), []byte("missing"))
+	if err != nil || len(bs) != 2 || !bytes.Equal(bs[0], payload) || bs[1] != nil {
+		t.Errorf("BytesSliceB: %q, %v", bs, err)
+	}
+}
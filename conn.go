@@ -0,0 +1,403 @@
+package redisb
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"math/big"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Conn owns a single connection to a Redis server along with the buffered
+// reader and Writer used on it, so bytes read ahead while decoding one reply
+// are still there for the next one.
+//
+// A Conn is itself an io.ReadWriter, so every package-level helper accepts it.
+type Conn struct {
+	conn io.ReadWriter
+	r    *bufio.Reader
+	w    *Writer
+
+	strict bool
+	opts   DecoderOptions
+	err    error
+	stream *bulkReader
+
+	readTimeout  time.Duration
+	writeTimeout time.Duration
+
+	dmu         sync.Mutex // guards the deadlines against context cancellation
+	ctxDeadline time.Time
+	ctx         context.Context // of the current call, if it has one
+	canceled    bool
+
+	created  time.Time
+	activity atomic.Int64 // UnixNano of the last successful read or write
+
+	redial func(context.Context) (net.Conn, error)
+	setup  func(*Conn) error
+	retry  *RetryPolicy
+	gen    int // bumped by every reconnect
+
+	pool  *Pool // the Pool c is checked out from, if any
+	state connState
+}
+
+// connState is the server-side state left by commands sent on a Conn, which
+// a Pool must not hand on to the next caller.
+type connState struct {
+	multi, watching, subscribed, selected bool
+}
+
+// dirty reports whether c is in a state Pool.Put does not take back.
+func (s connState) dirty() bool {
+	return s.multi || s.watching || s.subscribed || s.selected
+}
+
+// note records the state the command args leaves c in.
+func (c *Conn) note(args interface{}) {
+	switch commandName(args) {
+	case "multi":
+		c.state.multi = true
+	case "exec", "discard":
+		c.state.multi, c.state.watching = false, false
+	case "watch":
+		c.state.watching = true
+	case "unwatch":
+		c.state.watching = false
+	case "subscribe", "psubscribe", "ssubscribe":
+		c.state.subscribed = true
+	case "select":
+		c.state.selected = true
+	case "reset":
+		c.state = connState{selected: true}
+	}
+}
+
+func NewConn(nc net.Conn) *Conn {
+	return newConn(nc)
+}
+
+func newConn(rw io.ReadWriter) *Conn {
+	c := &Conn{conn: rw, r: bufio.NewReader(rw), w: NewWriter(rw), created: time.Now()}
+	c.touch()
+	return c
+}
+
+func (c *Conn) Read(p []byte) (int, error) {
+	return c.r.Read(p)
+}
+
+func (c *Conn) Write(p []byte) (int, error) {
+	return c.conn.Write(p)
+}
+
+func (c *Conn) Close() error {
+	if cl, ok := c.conn.(io.Closer); ok {
+		return cl.Close()
+	}
+	return nil
+}
+
+// Ping sends PING and checks that the server answers PONG.
+func (c *Conn) Ping() error {
+	s, err := c.String("ping")
+	if err != nil {
+		return err
+	}
+	if s != "PONG" {
+		return newConversionError("Unexpected reply to PING: %q", s)
+	}
+	return nil
+}
+
+// LastActivity returns when c last wrote a command or read a reply, or when
+// it was created if it has done neither.
+func (c *Conn) LastActivity() time.Time {
+	return time.Unix(0, c.activity.Load())
+}
+
+func (c *Conn) touch() {
+	c.activity.Store(time.Now().UnixNano())
+}
+
+// Err returns the error that left c unusable, if any. Once a reply could not
+// be read in full the stream is out of step, and every later call returns
+// this error instead of talking to the server.
+func (c *Conn) Err() error {
+	return c.err
+}
+
+func (c *Conn) do(args interface{}, bytes bool) (interface{}, error) {
+	if c.retry != nil {
+		return c.retry.do(c, args, bytes)
+	}
+	return c.doOnce(args, bytes)
+}
+
+func (c *Conn) doOnce(args interface{}, bytes bool) (interface{}, error) {
+	if err := c.send(args); err != nil {
+		return nil, err
+	}
+	return c.receive(bytes)
+}
+
+func (c *Conn) send(args interface{}) error {
+	if err := c.ready(); err != nil {
+		return err
+	}
+	if err := c.w.appendArgs(args); err != nil {
+		return err
+	}
+	c.note(args)
+	return c.flush()
+}
+
+// ready reports why no command can be sent on c, if it can not.
+func (c *Conn) ready() error {
+	if c.err != nil {
+		return c.err
+	}
+	if c.stream != nil {
+		return ErrStreamOpen
+	}
+	return nil
+}
+
+func (c *Conn) flush() error {
+	c.setDeadline(c.writeTimeout, deadliner.SetWriteDeadline)
+	if err := c.w.Flush(); err != nil {
+		return c.fail(err)
+	}
+	c.touch()
+	return nil
+}
+
+func (c *Conn) receive(bytes bool) (interface{}, error) {
+	r, err := c.receiveReply()
+	if err != nil {
+		return nil, err
+	}
+	return r.value(bytes)
+}
+
+func (c *Conn) receiveReply() (Reply, error) {
+	c.setDeadline(c.readTimeout, deadliner.SetReadDeadline)
+	r, err := c.decoder(false).reply()
+	if err != nil {
+		return Reply{}, c.fail(err)
+	}
+	c.touch()
+	return r, nil
+}
+
+// Reply sends a command and returns its reply with the RESP type intact.
+// Error replies are returned as a Reply of KindError, not as an error.
+func (c *Conn) Reply(args ...string) (Reply, error) {
+	if err := c.send(args); err != nil {
+		return Reply{}, err
+	}
+	return c.receiveReply()
+}
+
+type deadliner interface {
+	SetReadDeadline(t time.Time) error
+	SetWriteDeadline(t time.Time) error
+}
+
+// setDeadline applies timeout, or the deadline of the context of the current
+// call if that is sooner, with set.
+func (c *Conn) setDeadline(timeout time.Duration, set func(deadliner, time.Time) error) {
+	c.dmu.Lock()
+	defer c.dmu.Unlock()
+	d, ok := c.conn.(deadliner)
+	if !ok || c.canceled {
+		return
+	}
+	var t time.Time
+	if timeout > 0 {
+		t = time.Now().Add(timeout)
+	}
+	if !c.ctxDeadline.IsZero() && (t.IsZero() || c.ctxDeadline.Before(t)) {
+		t = c.ctxDeadline
+	}
+	set(d, t)
+}
+
+// withContext runs f with the deadline of ctx applied to c, and interrupts
+// any read or write in progress when ctx is canceled, by closing the
+// connection if it has no deadlines. A call interrupted that way returns
+// ctx.Err() and leaves c unusable.
+func (c *Conn) withContext(ctx context.Context, f func() error) error {
+	if ctx.Done() == nil {
+		return f()
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	c.dmu.Lock()
+	c.ctxDeadline, _ = ctx.Deadline()
+	c.ctx = ctx
+	c.dmu.Unlock()
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		select {
+		case <-ctx.Done():
+			c.dmu.Lock()
+			c.canceled = true
+			if d, ok := c.conn.(deadliner); ok {
+				d.SetReadDeadline(time.Unix(1, 0))
+				d.SetWriteDeadline(time.Unix(1, 0))
+			} else if cl, ok := c.conn.(io.Closer); ok {
+				cl.Close()
+			}
+			c.dmu.Unlock()
+		case <-stop:
+		}
+	}()
+	err := f()
+	close(stop)
+	<-done
+	c.dmu.Lock()
+	deadline, canceled := c.ctxDeadline, c.canceled
+	c.ctxDeadline = time.Time{}
+	c.ctx = nil
+	c.canceled = false
+	c.dmu.Unlock()
+	if err == nil || c.err == nil {
+		return err
+	}
+	// only a failure caused by ctx is reported as ctx.Err()
+	var te TimeoutError
+	switch {
+	case canceled && ctx.Err() != nil:
+		c.err = ctx.Err()
+	case errors.As(c.err, &te) && !deadline.IsZero() && !time.Now().Before(deadline):
+		c.err = context.DeadlineExceeded
+	}
+	return c.err
+}
+
+func (c *Conn) doContext(ctx context.Context, args interface{}, bytes bool) (interface{}, error) {
+	var i interface{}
+	err := c.withContext(ctx, func() error {
+		var err error
+		i, err = c.do(args, bytes)
+		return err
+	})
+	return i, err
+}
+
+// callContext returns the context of the current call, or
+// context.Background() if it has none.
+func (c *Conn) callContext() context.Context {
+	c.dmu.Lock()
+	defer c.dmu.Unlock()
+	if c.ctx == nil {
+		return context.Background()
+	}
+	return c.ctx
+}
+
+// reconnect replaces the connection underneath c with a new one from the
+// Dial that created c, dialed within ctx, and sets it up the same way.
+func (c *Conn) reconnect(ctx context.Context) error {
+	if c.redial == nil {
+		return c.err
+	}
+	c.Close()
+	nc, err := c.redial(ctx)
+	if err != nil {
+		return err
+	}
+	c.dmu.Lock()
+	c.conn = nc
+	if c.canceled {
+		// the call was canceled while dialing, too late to interrupt nc
+		nc.SetReadDeadline(time.Unix(1, 0))
+		nc.SetWriteDeadline(time.Unix(1, 0))
+	}
+	c.dmu.Unlock()
+	c.r.Reset(nc)
+	c.w.Reset(nc)
+	c.err = nil
+	c.stream = nil
+	c.created = time.Now()
+	c.touch()
+	c.gen++
+	retry := c.retry
+	c.retry = nil
+	err = c.setup(c)
+	c.retry = retry
+	if err != nil {
+		c.fail(err)
+		return err
+	}
+	return nil
+}
+
+// fail leaves c unusable because of err, which is reported as a TimeoutError
+// if it was caused by a deadline expiring.
+func (c *Conn) fail(err error) error {
+	c.err = timeoutError(err)
+	return c.err
+}
+
+func timeoutError(err error) error {
+	var ne net.Error
+	if errors.As(err, &ne) && ne.Timeout() {
+		return TimeoutError{err}
+	}
+	return err
+}
+
+func (c *Conn) decoder(bytes bool) *decoder {
+	return &decoder{r: c.r, bytes: bytes, strict: c.strict, opts: c.opts}
+}
+
+// SetDecoderOptions limits every later reply on c as with DecodeWithOptions.
+func (c *Conn) SetDecoderOptions(opts DecoderOptions) {
+	c.opts = opts
+}
+
+// SetReadTimeout bounds how long each later reply on c may take to arrive.
+// Zero means no limit.
+func (c *Conn) SetReadTimeout(d time.Duration) {
+	c.readTimeout = d
+}
+
+// SetWriteTimeout bounds how long each later command on c may take to be
+// written. Zero means no limit.
+func (c *Conn) SetWriteTimeout(d time.Duration) {
+	c.writeTimeout = d
+}
+
+// SetStrict makes every later reply on c be decoded as with DecodeStrict.
+func (c *Conn) SetStrict(strict bool) {
+	c.strict = strict
+}
+
+// Do sends a command whose arguments may be of any type Args accepts.
+func (c *Conn) Do(args ...interface{}) (interface{}, error) { return RawArgs(c, args) }
+
+func (c *Conn) Raw(args ...string) (interface{}, error)     { return Raw(c, args...) }
+func (c *Conn) Int64(args ...string) (int64, error)         { return Int64(c, args...) }
+func (c *Conn) Bool(args ...string) (bool, error)           { return Bool(c, args...) }
+func (c *Conn) String(args ...string) (string, error)       { return String(c, args...) }
+func (c *Conn) Array(args ...string) ([]interface{}, error) { return Array(c, args...) }
+func (c *Conn) Bools(args ...string) ([]bool, error)        { return Bools(c, args...) }
+func (c *Conn) Int64s(args ...string) ([]int64, error)      { return Int64s(c, args...) }
+func (c *Conn) Strings(args ...string) ([]string, error)    { return Strings(c, args...) }
+func (c *Conn) Float64(args ...string) (float64, error)     { return Float64(c, args...) }
+func (c *Conn) BigInt(args ...string) (*big.Int, error)     { return BigInt(c, args...) }
+func (c *Conn) Map(args ...string) (map[string]interface{}, error) {
+	return Map(c, args...)
+}
+func (c *Conn) Bytes(args ...string) ([]byte, error)        { return Bytes(c, args...) }
+func (c *Conn) BytesSlice(args ...string) ([][]byte, error) { return BytesSlice(c, args...) }
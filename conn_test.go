@@ -0,0 +1,105 @@
+package redisb
+
+import (
+	"bufio"
+	"net"
+	"testing"
+)
+
+// fakeServer answers each command read from the client half of a net.Pipe
+// with whatever handle returns for it.
+func fakeServer(t *testing.T, handle func(cmd []string) string) *Conn {
+	client, server := net.Pipe()
+	t.Cleanup(func() { client.Close(); server.Close() })
+	go serve(server, handle)
+	return NewConn(client)
+}
+
+// listenServer is fakeServer on a loopback TCP listener, for use with Dial.
+func listenServer(t *testing.T, handle func(cmd []string) string) string {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return acceptServer(t, l, handle)
+}
+
+// acceptServer runs serve with handle on every connection accepted by l.
+func acceptServer(t *testing.T, l net.Listener, handle func(cmd []string) string) string {
+	t.Cleanup(func() { l.Close() })
+	go func() {
+		for {
+			nc, err := l.Accept()
+			if err != nil {
+				return
+			}
+			t.Cleanup(func() { nc.Close() })
+			go serve(nc, handle)
+		}
+	}()
+	return l.Addr().String()
+}
+
+// hangUp makes serve close the connection instead of replying.
+const hangUp = "\x00hang up"
+
+func serve(nc net.Conn, handle func(cmd []string) string) {
+	r := bufio.NewReader(nc)
+	for {
+		i, err := Decode(r)
+		if err != nil {
+			return
+		}
+		cmd, _ := toStrings(i)
+		reply := handle(cmd)
+		if reply == hangUp {
+			nc.Close()
+			return
+		}
+		if _, err := nc.Write([]byte(reply)); err != nil {
+			return
+		}
+	}
+}
+
+func TestConnKeepsReadAhead(t *testing.T) {
+	calls := 0
+	c := fakeServer(t, func(cmd []string) string {
+		calls++
+		if calls == 1 {
+			return "+first\r\n:2\r\n"
+		}
+		return ""
+	})
+	s, err := c.String("get", "a")
+	if err != nil || s != "first" {
+		t.Fatalf("String: %q, %v", s, err)
+	}
+	i, err := Int64(c, "incr", "b")
+	if err != nil || i != 2 {
+		t.Fatalf("Int64: %d, %v", i, err)
+	}
+}
+
+func TestConnHelpers(t *testing.T) {
+	c := fakeServer(t, func(cmd []string) string {
+		switch cmd[0] {
+		case "lrange":
+			return "*2\r\n$1\r\na\r\n$1\r\nb\r\n"
+		case "exists":
+			return ":1\r\n"
+		}
+		return "-ERR unknown command\r\n"
+	})
+	ss, err := c.Strings("lrange", "l", "0", "-1")
+	if err != nil || len(ss) != 2 || ss[0] != "a" || ss[1] != "b" {
+		t.Errorf("Strings: %q, %v", ss, err)
+	}
+	b, err := Exists(c, "k")
+	if err != nil || !b {
+		t.Errorf("Exists: %v, %v", b, err)
+	}
+	if _, err := c.Raw("nope"); err == nil {
+		t.Error("Raw: expected RedisError")
+	}
+}
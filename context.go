@@ -0,0 +1,120 @@
+package redisb
+
+import (
+	"context"
+	"io"
+	"math/big"
+)
+
+// The Context variants of the typed helpers apply the deadline of ctx to the
+// connection, and give up with ctx.Err() as soon as ctx is canceled. A call
+// given up part way through leaves a Conn unusable.
+//
+// Interrupting a call needs a connection with SetReadDeadline and
+// SetWriteDeadline, such as a net.Conn. One without them that is an io.Closer
+// is closed instead. On any other io.ReadWriter a canceled call only returns
+// once the read or write it is blocked in does.
+
+func doContext(ctx context.Context, rw io.ReadWriter, args interface{}) (interface{}, error) {
+	return connFor(rw).doContext(ctx, args, false)
+}
+
+func RawContext(ctx context.Context, rw io.ReadWriter, args ...string) (interface{}, error) {
+	return doContext(ctx, rw, args)
+}
+
+func Int64Context(ctx context.Context, rw io.ReadWriter, args ...string) (int64, error) {
+	i, err := doContext(ctx, rw, args)
+	if err != nil {
+		return 0, err
+	}
+	return toInt64(i)
+}
+
+func BoolContext(ctx context.Context, rw io.ReadWriter, args ...string) (bool, error) {
+	i, err := doContext(ctx, rw, args)
+	if err != nil {
+		return false, err
+	}
+	return toBool(i)
+}
+
+func StringContext(ctx context.Context, rw io.ReadWriter, args ...string) (string, error) {
+	i, err := doContext(ctx, rw, args)
+	if err != nil {
+		return "", err
+	}
+	return toString(i)
+}
+
+func Float64Context(ctx context.Context, rw io.ReadWriter, args ...string) (float64, error) {
+	i, err := doContext(ctx, rw, args)
+	if err != nil {
+		return 0, err
+	}
+	return toFloat64(i)
+}
+
+func BigIntContext(ctx context.Context, rw io.ReadWriter, args ...string) (*big.Int, error) {
+	i, err := doContext(ctx, rw, args)
+	if err != nil {
+		return nil, err
+	}
+	return toBigInt(i)
+}
+
+func MapContext(ctx context.Context, rw io.ReadWriter, args ...string) (map[string]interface{}, error) {
+	i, err := doContext(ctx, rw, args)
+	if err != nil {
+		return nil, err
+	}
+	return toMap(i)
+}
+
+func ArrayContext(ctx context.Context, rw io.ReadWriter, args ...string) ([]interface{}, error) {
+	i, err := doContext(ctx, rw, args)
+	if err != nil {
+		return nil, err
+	}
+	return toArray(i)
+}
+
+func BoolsContext(ctx context.Context, rw io.ReadWriter, args ...string) ([]bool, error) {
+	i, err := doContext(ctx, rw, args)
+	if err != nil {
+		return nil, err
+	}
+	return toBools(i)
+}
+
+func Int64sContext(ctx context.Context, rw io.ReadWriter, args ...string) ([]int64, error) {
+	i, err := doContext(ctx, rw, args)
+	if err != nil {
+		return nil, err
+	}
+	return toInt64s(i)
+}
+
+func StringsContext(ctx context.Context, rw io.ReadWriter, args ...string) ([]string, error) {
+	i, err := doContext(ctx, rw, args)
+	if err != nil {
+		return nil, err
+	}
+	return toStrings(i)
+}
+
+func BytesContext(ctx context.Context, rw io.ReadWriter, args ...string) ([]byte, error) {
+	i, err := connFor(rw).doContext(ctx, args, true)
+	if err != nil {
+		return nil, err
+	}
+	return toBytes(i)
+}
+
+func BytesSliceContext(ctx context.Context, rw io.ReadWriter, args ...string) ([][]byte, error) {
+	i, err := connFor(rw).doContext(ctx, args, true)
+	if err != nil {
+		return nil, err
+	}
+	return toBytesSlice(i)
+}
@@ -0,0 +1,127 @@
+package redisb
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+)
+
+func TestContextCancel(t *testing.T) {
+	addr := listenServer(t, func(cmd []string) string {
+		if cmd[0] == "blpop" {
+			return ""
+		}
+		return "+PONG\r\n"
+	})
+	c, err := Dial("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := StringContext(canceled, c, "ping"); err != context.Canceled {
+		t.Errorf("StringContext: expected context.Canceled, got %#v", err)
+	}
+	if s, err := StringContext(context.Background(), c, "ping"); err != nil || s != "PONG" {
+		t.Fatalf("StringContext: %q, %v", s, err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(20*time.Millisecond, cancel)
+	if _, err := BlpopContext(ctx, c, "q", "0"); err != context.Canceled {
+		t.Errorf("BlpopContext: expected context.Canceled, got %#v", err)
+	}
+	if c.Err() != context.Canceled {
+		t.Errorf("Err: %#v", c.Err())
+	}
+}
+
+func TestContextDeadline(t *testing.T) {
+	addr := listenServer(t, func(cmd []string) string {
+		if cmd[0] == "brpop" {
+			return ""
+		}
+		return "+PONG\r\n"
+	})
+	c, err := Dial("tcp", addr, DialReadTimeout(time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Millisecond)
+	defer cancel()
+	if s, err := StringContext(ctx, c, "ping"); err != nil || s != "PONG" {
+		t.Fatalf("StringContext: %q, %v", s, err)
+	}
+	start := time.Now()
+	if _, err := BrpopContext(ctx, c, "q", "0"); err != context.DeadlineExceeded {
+		t.Errorf("BrpopContext: expected context.DeadlineExceeded, got %#v", err)
+	}
+	if time.Since(start) > 5*time.Second {
+		t.Errorf("BrpopContext: deadline ignored")
+	}
+}
+
+// lateContext ends once ended is closed, without closing its Done channel,
+// as if it ended just after a call failed for some other reason.
+type lateContext struct {
+	context.Context
+	ended chan struct{}
+}
+
+func (lc lateContext) Err() error {
+	select {
+	case <-lc.ended:
+		return context.Canceled
+	default:
+		return nil
+	}
+}
+
+func TestContextKeepsOtherErrors(t *testing.T) {
+	parent, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ctx := lateContext{parent, make(chan struct{})}
+	c := fakeServer(t, func(cmd []string) string {
+		close(ctx.ended)
+		return "?\r\n"
+	})
+	if _, err := StringContext(ctx, c, "get", "k"); err == context.Canceled {
+		t.Errorf("StringContext: ProtocolError replaced by %#v", err)
+	}
+	if _, ok := c.Err().(ProtocolError); !ok {
+		t.Errorf("Err: expected ProtocolError, got %#v", c.Err())
+	}
+}
+
+type closingPipe struct {
+	io.Reader
+	io.Writer
+	close func() error
+}
+
+func (cp closingPipe) Close() error { return cp.close() }
+
+func TestContextCancelWithoutDeadlines(t *testing.T) {
+	r, w := io.Pipe()
+	defer w.Close()
+	rw := closingPipe{r, io.Discard, r.Close}
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(20*time.Millisecond, cancel)
+	done := make(chan error, 1)
+	go func() {
+		_, err := StringContext(ctx, rw, "get", "k")
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err != context.Canceled {
+			t.Errorf("StringContext: expected context.Canceled, got %#v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("StringContext: not interrupted by cancel")
+	}
+}
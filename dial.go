@@ -0,0 +1,186 @@
+package redisb
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"time"
+)
+
+// Option configures a Conn created by Dial.
+type Option func(*dialOptions)
+
+type dialOptions struct {
+	protover int
+	hello    HelloOptions
+	decoder  DecoderOptions
+
+	dialTimeout  time.Duration
+	readTimeout  time.Duration
+	writeTimeout time.Duration
+
+	retry *RetryPolicy
+
+	tlsConfig *tls.Config
+}
+
+// DialHello runs HELLO with the given protocol version and options as soon
+// as the connection is established. Fields left zero in opts keep the values
+// given by DialUsername, DialPassword, DialClientName and DialDB.
+func DialHello(protover int, opts HelloOptions) Option {
+	return func(o *dialOptions) {
+		o.protover = protover
+		if opts.Username != "" {
+			o.hello.Username = opts.Username
+		}
+		if opts.Password != "" {
+			o.hello.Password = opts.Password
+		}
+		if opts.ClientName != "" {
+			o.hello.ClientName = opts.ClientName
+		}
+		if opts.DB != 0 {
+			o.hello.DB = opts.DB
+		}
+	}
+}
+
+// DialUsername sets the ACL user to AUTH as, which needs DialPassword too.
+func DialUsername(username string) Option {
+	return func(o *dialOptions) {
+		o.hello.Username = username
+	}
+}
+
+// DialPassword makes the Conn AUTH with password.
+func DialPassword(password string) Option {
+	return func(o *dialOptions) {
+		o.hello.Password = password
+	}
+}
+
+// DialClientName sets the name of the Conn with CLIENT SETNAME.
+func DialClientName(name string) Option {
+	return func(o *dialOptions) {
+		o.hello.ClientName = name
+	}
+}
+
+// DialDB makes the Conn SELECT db.
+func DialDB(db int) Option {
+	return func(o *dialOptions) {
+		o.hello.DB = db
+	}
+}
+
+// DialDecoderOptions sets the DecoderOptions used for every reply on the
+// connection.
+func DialDecoderOptions(opts DecoderOptions) Option {
+	return func(o *dialOptions) {
+		o.decoder = opts
+	}
+}
+
+// DialTimeout bounds how long establishing the connection may take.
+func DialTimeout(d time.Duration) Option {
+	return func(o *dialOptions) {
+		o.dialTimeout = d
+	}
+}
+
+// DialReadTimeout sets the read timeout of the Conn, see Conn.SetReadTimeout.
+func DialReadTimeout(d time.Duration) Option {
+	return func(o *dialOptions) {
+		o.readTimeout = d
+	}
+}
+
+// DialWriteTimeout sets the write timeout of the Conn, see
+// Conn.SetWriteTimeout.
+func DialWriteTimeout(d time.Duration) Option {
+	return func(o *dialOptions) {
+		o.writeTimeout = d
+	}
+}
+
+// DialRetry makes the Conn reconnect and retry failed commands as described
+// by policy.
+func DialRetry(policy RetryPolicy) Option {
+	return func(o *dialOptions) {
+		o.retry = &policy
+	}
+}
+
+// DialTLSConfig makes Dial use TLS configured by config. Unless config sets
+// ServerName, the host part of the dialed address is used for SNI and for
+// verifying the server certificate.
+func DialTLSConfig(config *tls.Config) Option {
+	return func(o *dialOptions) {
+		o.tlsConfig = config
+	}
+}
+
+func Dial(network, address string, options ...Option) (*Conn, error) {
+	o := &dialOptions{}
+	for _, option := range options {
+		option(o)
+	}
+	connect := func(ctx context.Context) (net.Conn, error) {
+		nc, err := (&net.Dialer{Timeout: o.dialTimeout}).DialContext(ctx, network, address)
+		if err != nil || o.tlsConfig == nil {
+			return nc, err
+		}
+		return o.handshake(ctx, nc, address)
+	}
+	nc, err := connect(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	c := NewConn(nc)
+	c.SetDecoderOptions(o.decoder)
+	c.SetReadTimeout(o.readTimeout)
+	c.SetWriteTimeout(o.writeTimeout)
+	c.redial = connect
+	c.setup = o.setup
+	if err := o.setup(c); err != nil {
+		nc.Close()
+		return nil, err
+	}
+	c.retry = o.retry
+	return c, nil
+}
+
+// handshake runs the TLS handshake on nc within the dial timeout and ctx.
+func (o *dialOptions) handshake(ctx context.Context, nc net.Conn, address string) (net.Conn, error) {
+	config := o.tlsConfig
+	if config.ServerName == "" {
+		if host, _, err := net.SplitHostPort(address); err == nil {
+			config = config.Clone()
+			config.ServerName = host
+		}
+	}
+	tc := tls.Client(nc, config)
+	if o.dialTimeout > 0 {
+		tc.SetDeadline(time.Now().Add(o.dialTimeout))
+	}
+	if err := tc.HandshakeContext(ctx); err != nil {
+		nc.Close()
+		return nil, err
+	}
+	tc.SetDeadline(time.Time{})
+	return tc, nil
+}
+
+// setup prepares a newly established connection, both in Dial and whenever
+// the Conn reconnects.
+func (o *dialOptions) setup(c *Conn) error {
+	var err error
+	if o.protover != 0 {
+		_, err = Hello(c, o.protover, o.hello)
+	} else if o.hello != (HelloOptions{}) {
+		_, err = helloFallback(c, o.hello)
+	}
+	// the SELECT sent here is the state every caller expects
+	c.state = connState{}
+	return err
+}
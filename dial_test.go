@@ -0,0 +1,131 @@
+package redisb
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestDialReadTimeout(t *testing.T) {
+	addr := listenServer(t, func(cmd []string) string {
+		if cmd[0] == "blpop" {
+			return ""
+		}
+		return "+PONG\r\n"
+	})
+	c, err := Dial("tcp", addr, DialTimeout(time.Second), DialReadTimeout(50*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	if s, err := c.String("ping"); err != nil || s != "PONG" {
+		t.Fatalf("ping: %q, %v", s, err)
+	}
+	_, err = c.Array("blpop", "q", "0")
+	te, ok := err.(TimeoutError)
+	if !ok || !te.Timeout() {
+		t.Fatalf("Array: expected TimeoutError, got %#v", err)
+	}
+	if c.Err() != err {
+		t.Errorf("Err: %#v", c.Err())
+	}
+	if _, err := c.String("ping"); err != te {
+		t.Errorf("String after timeout: %#v", err)
+	}
+}
+
+func TestWriteTimeout(t *testing.T) {
+	client, server := net.Pipe()
+	defer server.Close()
+	c := NewConn(client)
+	defer c.Close()
+	c.SetWriteTimeout(20 * time.Millisecond)
+	if _, err := c.String("ping"); err == nil {
+		t.Fatal("String: expected TimeoutError")
+	} else if _, ok := err.(TimeoutError); !ok {
+		t.Fatalf("String: expected TimeoutError, got %#v", err)
+	}
+}
+
+// testCert returns a self-signed certificate for localhost that can be used
+// by both ends of a TLS connection, and a pool trusting it.
+func testCert(t *testing.T) (tls.Certificate, *x509.CertPool) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "localhost"},
+		DNSNames:              []string{"localhost"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	leaf, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pool := x509.NewCertPool()
+	pool.AddCert(leaf)
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key, Leaf: leaf}, pool
+}
+
+func TestDialTLS(t *testing.T) {
+	cert, pool := testCert(t)
+	var mu sync.Mutex
+	var serverName string
+	l, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		ClientCAs:    pool,
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		GetConfigForClient: func(hello *tls.ClientHelloInfo) (*tls.Config, error) {
+			mu.Lock()
+			serverName = hello.ServerName
+			mu.Unlock()
+			return nil, nil
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, port, _ := net.SplitHostPort(acceptServer(t, l, func(cmd []string) string {
+		return "+PONG\r\n"
+	}))
+	addr := net.JoinHostPort("localhost", port)
+	config := &tls.Config{RootCAs: pool, Certificates: []tls.Certificate{cert}, MinVersion: tls.VersionTLS12}
+	c, err := Dial("tcp", addr, DialTimeout(time.Second), DialTLSConfig(config))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	if err := c.Ping(); err != nil {
+		t.Fatalf("Ping: %v", err)
+	}
+	mu.Lock()
+	if serverName != "localhost" {
+		t.Errorf("SNI: %q", serverName)
+	}
+	mu.Unlock()
+	if config.ServerName != "" {
+		t.Errorf("DialTLSConfig modified config: %q", config.ServerName)
+	}
+	if _, err := Dial("tcp", addr, DialTLSConfig(&tls.Config{Certificates: []tls.Certificate{cert}})); err == nil {
+		t.Error("Dial: expected error for untrusted server certificate")
+	}
+}
@@ -1,6 +1,7 @@
 package redisb
 
 import (
+	"context"
 	"fmt"
 	"io"
 )
@@ -77,6 +78,9 @@ func Ltrim(rw io.ReadWriter, args ...string) (bool, error) {
 func Brpoplpush(rw io.ReadWriter, args ...string) (string, error) {
 	return String(rw, prepend("brpoplpush", args)...)
 }
+func BrpoplpushContext(ctx context.Context, rw io.ReadWriter, args ...string) (string, error) {
+	return StringContext(ctx, rw, prepend("brpoplpush", args)...)
+}
 func Rpoplpush(rw io.ReadWriter, args ...string) (string, error) {
 	return String(rw, prepend("rpoplpush", args)...)
 }
@@ -97,6 +101,12 @@ func Blpop(rw io.ReadWriter, args ...string) ([]interface{}, error) {
 func Brpop(rw io.ReadWriter, args ...string) ([]interface{}, error) {
 	return Array(rw, prepend("brpop", args)...)
 }
+func BlpopContext(ctx context.Context, rw io.ReadWriter, args ...string) ([]interface{}, error) {
+	return ArrayContext(ctx, rw, prepend("blpop", args)...)
+}
+func BrpopContext(ctx context.Context, rw io.ReadWriter, args ...string) ([]interface{}, error) {
+	return ArrayContext(ctx, rw, prepend("brpop", args)...)
+}
 func Lrange(rw io.ReadWriter, args ...string) ([]interface{}, error) {
 	return Array(rw, prepend("lrange", args)...)
 }
@@ -373,6 +383,9 @@ func ScriptLoad(rw io.ReadWriter, args ...string) (bool, error) {
 
 // TRANSACTION
 // DISCARD EXEC MULTI UNWATCH WATCH
+
+// Multi only sends MULTI, after which the server answers QUEUED to every
+// command. Conn.Tx queues commands with typed results instead.
 func Multi(rw io.ReadWriter) (io.ReadWriter, error) {
 	result, err := Bool(rw, "multi")
 	if err != nil {
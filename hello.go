@@ -0,0 +1,152 @@
+package redisb
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// HelloOptions carries the credentials and connection settings sent along
+// with HELLO, or with AUTH, CLIENT SETNAME and SELECT on servers without it.
+type HelloOptions struct {
+	Username   string
+	Password   string
+	ClientName string
+	DB         int
+}
+
+// HelloInfo is the server information returned by HELLO.
+type HelloInfo struct {
+	Server  string
+	Version string
+	Proto   int64
+	ID      int64
+	Mode    string
+	Role    string
+	Modules []interface{}
+}
+
+// Hello switches rw to the given protocol version. Servers that reject
+// HELLO as an unknown command are set up with AUTH, CLIENT SETNAME and SELECT
+// instead, and the returned HelloInfo only reports protocol version 2.
+func Hello(rw io.ReadWriter, protover int, opts HelloOptions) (HelloInfo, error) {
+	args := []string{"hello", strconv.Itoa(protover)}
+	if opts.Password != "" {
+		user := opts.Username
+		if user == "" {
+			user = "default"
+		}
+		args = append(args, "auth", user, opts.Password)
+	}
+	if opts.ClientName != "" {
+		args = append(args, "setname", opts.ClientName)
+	}
+	m, err := Map(rw, args...)
+	if isUnknownCommand(err) {
+		return helloFallback(rw, opts)
+	}
+	if err != nil {
+		return HelloInfo{}, newSetupError(args, err)
+	}
+	info, err := toHelloInfo(m)
+	if err != nil {
+		return HelloInfo{}, err
+	}
+	if opts.DB != 0 {
+		if err := setupCommand(rw, "select", strconv.Itoa(opts.DB)); err != nil {
+			return HelloInfo{}, err
+		}
+	}
+	return info, nil
+}
+
+func helloFallback(rw io.ReadWriter, opts HelloOptions) (HelloInfo, error) {
+	if opts.Password != "" {
+		args := []string{"auth", opts.Password}
+		if opts.Username != "" {
+			args = []string{"auth", opts.Username, opts.Password}
+		}
+		if err := setupCommand(rw, args...); err != nil {
+			return HelloInfo{}, err
+		}
+	}
+	if opts.ClientName != "" {
+		if err := setupCommand(rw, "client", "setname", opts.ClientName); err != nil {
+			return HelloInfo{}, err
+		}
+	}
+	if opts.DB != 0 {
+		if err := setupCommand(rw, "select", strconv.Itoa(opts.DB)); err != nil {
+			return HelloInfo{}, err
+		}
+	}
+	return HelloInfo{Proto: 2}, nil
+}
+
+func setupCommand(rw io.ReadWriter, args ...string) error {
+	if _, err := Bool(rw, args...); err != nil {
+		return newSetupError(args, err)
+	}
+	return nil
+}
+
+// SetupError reports a failed HELLO, AUTH, CLIENT SETNAME or SELECT. The
+// password is redacted from Command.
+type SetupError struct {
+	Command string
+	Err     error
+}
+
+func (se SetupError) Error() string {
+	return fmt.Sprintf("%s failed: %s", se.Command, se.Err)
+}
+
+func (se SetupError) Unwrap() error {
+	return se.Err
+}
+
+// newSetupError redacts the password by its position: last in AUTH, and
+// after the username following AUTH in HELLO.
+func newSetupError(args []string, err error) SetupError {
+	redacted := append([]string(nil), args...)
+	switch {
+	case args[0] == "auth":
+		redacted[len(args)-1] = "<redacted>"
+	case args[0] == "hello" && len(args) > 4 && args[2] == "auth":
+		redacted[4] = "<redacted>"
+	}
+	return SetupError{strings.ToUpper(args[0]) + " " + strings.Join(redacted[1:], " "), err}
+}
+
+func isUnknownCommand(err error) bool {
+	re, ok := err.(RedisError)
+	return ok && re.Prefix == "ERR" && strings.HasPrefix(strings.ToLower(re.Suffix), "unknown command")
+}
+
+func toHelloInfo(m map[string]interface{}) (HelloInfo, error) {
+	var info HelloInfo
+	var err error
+	for k, v := range m {
+		switch k {
+		case "server":
+			info.Server, err = toString(v)
+		case "version":
+			info.Version, err = toString(v)
+		case "proto":
+			info.Proto, err = toInt64(v)
+		case "id":
+			info.ID, err = toInt64(v)
+		case "mode":
+			info.Mode, err = toString(v)
+		case "role":
+			info.Role, err = toString(v)
+		case "modules":
+			info.Modules, _ = v.([]interface{})
+		}
+		if err != nil {
+			return HelloInfo{}, newConversionError("Conversion of HELLO %s failed: %s", k, err)
+		}
+	}
+	return info, nil
+}
@@ -0,0 +1,139 @@
+package redisb
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestHello(t *testing.T) {
+	var got []string
+	c := fakeServer(t, func(cmd []string) string {
+		got = cmd
+		return "%4\r\n+server\r\n+redis\r\n+version\r\n+7.2.0\r\n+proto\r\n:3\r\n+id\r\n:12\r\n"
+	})
+	info, err := Hello(c, 3, HelloOptions{Password: "secret", ClientName: "app"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := HelloInfo{Server: "redis", Version: "7.2.0", Proto: 3, ID: 12}
+	if !reflect.DeepEqual(info, want) {
+		t.Errorf("Hello: %#v - %#v", want, info)
+	}
+	if strings.Join(got, " ") != "hello 3 auth default secret setname app" {
+		t.Errorf("Hello sent: %q", got)
+	}
+}
+
+func TestHelloFallback(t *testing.T) {
+	var sent []string
+	c := fakeServer(t, func(cmd []string) string {
+		sent = append(sent, strings.Join(cmd, " "))
+		if cmd[0] == "hello" {
+			return "-ERR unknown command 'HELLO'\r\n"
+		}
+		return "+OK\r\n"
+	})
+	info, err := Hello(c, 3, HelloOptions{Username: "u", Password: "p", DB: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Proto != 2 {
+		t.Errorf("Hello fallback proto: %d", info.Proto)
+	}
+	want := []string{"hello 3 auth u p", "auth u p", "select 2"}
+	if !reflect.DeepEqual(sent, want) {
+		t.Errorf("Hello fallback sent: %q", sent)
+	}
+}
+
+func TestDialHello(t *testing.T) {
+	addr := listenServer(t, func(cmd []string) string {
+		if cmd[0] == "hello" {
+			return "*2\r\n+proto\r\n:" + cmd[1] + "\r\n"
+		}
+		return "+PONG\r\n"
+	})
+	c, err := Dial("tcp", addr, DialHello(3, HelloOptions{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	if s, err := c.String("ping"); err != nil || s != "PONG" {
+		t.Errorf("ping: %q, %v", s, err)
+	}
+}
+
+func TestDialSetup(t *testing.T) {
+	var mu sync.Mutex
+	var sent []string
+	gets := 0
+	addr := listenServer(t, func(cmd []string) string {
+		mu.Lock()
+		defer mu.Unlock()
+		sent = append(sent, strings.Join(cmd, " "))
+		if cmd[0] == "get" {
+			gets++
+			if gets == 1 {
+				return hangUp
+			}
+			return "$1\r\nv\r\n"
+		}
+		return "+OK\r\n"
+	})
+	c, err := Dial("tcp", addr, DialUsername("u"), DialPassword("p"), DialClientName("app"), DialDB(2), DialRetry(RetryPolicy{MaxAttempts: 2}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	if s, err := c.String("get", "k"); err != nil || s != "v" {
+		t.Fatalf("get: %q, %v", s, err)
+	}
+	setup := []string{"auth u p", "client setname app", "select 2"}
+	want := append(append(append(setup, "get k"), setup...), "get k")
+	mu.Lock()
+	defer mu.Unlock()
+	if !reflect.DeepEqual(sent, want) {
+		t.Errorf("Dial setup sent: %q", sent)
+	}
+}
+
+func TestSetupErrorRedacted(t *testing.T) {
+	for _, protover := range []int{0, 3} {
+		addr := listenServer(t, func(cmd []string) string {
+			return "-WRONGPASS invalid username-password pair\r\n"
+		})
+		options := []Option{DialUsername("u"), DialPassword("hunter2")}
+		if protover != 0 {
+			options = append(options, DialHello(protover, HelloOptions{}))
+		}
+		_, err := Dial("tcp", addr, options...)
+		var re RedisError
+		if !errors.As(err, &re) || re.Prefix != "WRONGPASS" {
+			t.Fatalf("protover %d: %v", protover, err)
+		}
+		if strings.Contains(err.Error(), "hunter2") || !strings.Contains(err.Error(), "<redacted>") {
+			t.Errorf("protover %d: password not redacted: %v", protover, err)
+		}
+	}
+}
+
+func TestNewSetupError(t *testing.T) {
+	cases := []struct {
+		in  []string
+		out string
+	}{
+		{[]string{"hello", "3", "auth", "default", "3"}, "HELLO 3 auth default <redacted>"},
+		{[]string{"hello", "3", "auth", "u", "p", "setname", "p"}, "HELLO 3 auth u <redacted> setname p"},
+		{[]string{"auth", "secret"}, "AUTH <redacted>"},
+		{[]string{"auth", "u", "xsecretx"}, "AUTH u <redacted>"},
+		{[]string{"client", "setname", "secret"}, "CLIENT setname secret"},
+	}
+	for _, c := range cases {
+		if se := newSetupError(c.in, nil); se.Command != c.out {
+			t.Errorf("newSetupError: %q: %q - %q", c.in, c.out, se.Command)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package redisb
+
+import (
+	"bufio"
+	"fmt"
+)
+
+// DecoderOptions bounds the replies a decoder accepts. A zero field means no
+// limit, except for MaxDepth, which then defaults to DefaultMaxDepth.
+//
+// Even without MaxBulkLen or MaxTotalBytes, the memory taken by a Bulk String
+// only grows as its payload actually arrives, so a false size in its header
+// can not force a huge allocation on its own.
+type DecoderOptions struct {
+	// MaxBulkLen is the largest Bulk, Verbatim String or Blob Error payload.
+	MaxBulkLen int64
+	// MaxArrayLen is the largest number of elements in an Array, Set, Push,
+	// Map or Attribute.
+	MaxArrayLen int64
+	// MaxDepth is the deepest nesting of aggregate replies. A negative value
+	// means no limit.
+	MaxDepth int
+	// MaxTotalBytes is the most bytes read for a single reply.
+	MaxTotalBytes int64
+}
+
+// LimitError reports a reply that went over one of the DecoderOptions. The
+// rest of the reply is left unread, so the connection can not be used again.
+type LimitError struct {
+	Limit string
+	Value int64
+	Max   int64
+}
+
+// DefaultMaxDepth is the MaxDepth used when DecoderOptions leave it zero,
+// including by Decode and every Conn, so deeply nested replies can not
+// exhaust the stack.
+const DefaultMaxDepth = 512
+
+func (le LimitError) Error() string {
+	return fmt.Sprintf("Reply exceeds %s: %d > %d", le.Limit, le.Value, le.Max)
+}
+
+func DecodeWithOptions(r *bufio.Reader, opts DecoderOptions) (interface{}, error) {
+	return (&decoder{r: r, opts: opts}).decode()
+}
+
+func (d *decoder) checkBulk(n uint64) error {
+	if d.opts.MaxBulkLen > 0 && n > uint64(d.opts.MaxBulkLen) {
+		return LimitError{"MaxBulkLen", toLimitValue(n), d.opts.MaxBulkLen}
+	}
+	return d.checkTotal(n + 2)
+}
+
+func (d *decoder) checkTotal(n uint64) error {
+	if d.opts.MaxTotalBytes > 0 && n > uint64(d.opts.MaxTotalBytes-d.offset) {
+		return LimitError{"MaxTotalBytes", toLimitValue(n + uint64(d.offset)), d.opts.MaxTotalBytes}
+	}
+	return nil
+}
+
+func (d *decoder) checkAggregate(n uint64) error {
+	if d.opts.MaxArrayLen > 0 && n > uint64(d.opts.MaxArrayLen) {
+		return LimitError{"MaxArrayLen", toLimitValue(n), d.opts.MaxArrayLen}
+	}
+	maxDepth := d.opts.MaxDepth
+	if maxDepth == 0 {
+		maxDepth = DefaultMaxDepth
+	}
+	if maxDepth > 0 && d.depth >= maxDepth {
+		return LimitError{"MaxDepth", int64(d.depth + 1), int64(maxDepth)}
+	}
+	return nil
+}
+
+func toLimitValue(n uint64) int64 {
+	if n > 1<<63-1 {
+		return 1<<63 - 1
+	}
+	return int64(n)
+}
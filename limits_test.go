@@ -0,0 +1,77 @@
+package redisb
+
+import (
+	"bufio"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestDecoderLimits(t *testing.T) {
+	cases := []struct {
+		in    string
+		opts  DecoderOptions
+		limit string
+	}{
+		{"$9999999999\r\n", DecoderOptions{MaxBulkLen: 1024}, "MaxBulkLen"},
+		{"*1000\r\n", DecoderOptions{MaxArrayLen: 10}, "MaxArrayLen"},
+		{"%1000\r\n", DecoderOptions{MaxArrayLen: 10}, "MaxArrayLen"},
+		{"*1\r\n*1\r\n*1\r\n:1\r\n", DecoderOptions{MaxDepth: 2}, "MaxDepth"},
+		{"$20\r\n", DecoderOptions{MaxTotalBytes: 16}, "MaxTotalBytes"},
+		{"+" + strings.Repeat("a", 100), DecoderOptions{MaxTotalBytes: 16}, "MaxTotalBytes"},
+		{strings.Repeat("*1\r\n", DefaultMaxDepth+1), DecoderOptions{}, "MaxDepth"},
+	}
+	for _, c := range cases {
+		_, err := DecodeWithOptions(bufio.NewReader(strings.NewReader(c.in)), c.opts)
+		le, ok := err.(LimitError)
+		if !ok || le.Limit != c.limit {
+			t.Errorf("DecodeWithOptions: %q: expected %s LimitError, got %#v", c.in, c.limit, err)
+		}
+	}
+	in := "*1\r\n*1\r\n$3\r\nabc\r\n"
+	opts := DecoderOptions{MaxBulkLen: 3, MaxArrayLen: 1, MaxDepth: 2, MaxTotalBytes: int64(len(in))}
+	if _, err := DecodeWithOptions(bufio.NewReader(strings.NewReader(in)), opts); err != nil {
+		t.Errorf("DecodeWithOptions: reply within limits failed: %s", err)
+	}
+}
+
+func TestDecodeUnlimited(t *testing.T) {
+	if _, err := Decode(bufio.NewReader(strings.NewReader("$99999999999\r\nabc"))); err == nil {
+		t.Error("Decode: expected error for truncated Bulk String")
+	}
+	payload := strings.Repeat("x", 3*bulkChunk+5)
+	in := "$" + strconv.Itoa(len(payload)) + "\r\n" + payload + "\r\n"
+	if s, err := Decode(bufio.NewReader(strings.NewReader(in))); err != nil || s != payload {
+		t.Errorf("Decode: Bulk String over several chunks: %v", err)
+	}
+	deep := strings.Repeat("*1\r\n", DefaultMaxDepth+1) + ":1\r\n"
+	if _, err := DecodeWithOptions(bufio.NewReader(strings.NewReader(deep)), DecoderOptions{MaxDepth: -1}); err != nil {
+		t.Errorf("DecodeWithOptions: MaxDepth -1: %v", err)
+	}
+}
+
+func TestConnLimitMarksUnusable(t *testing.T) {
+	c := fakeServer(t, func(cmd []string) string {
+		return "$9999999999\r\n"
+	})
+	c.SetDecoderOptions(DecoderOptions{MaxBulkLen: 1 << 20})
+	if _, err := c.String("get", "k"); err == nil {
+		t.Fatal("String: expected LimitError")
+	}
+	if _, ok := c.Err().(LimitError); !ok {
+		t.Fatalf("Err: expected LimitError, got %#v", c.Err())
+	}
+	if _, err := c.String("get", "k"); err != c.Err() {
+		t.Errorf("String: expected Conn error on unusable Conn, got %#v", err)
+	}
+	c = fakeServer(t, func(cmd []string) string {
+		return "$9999999999\r\n"
+	})
+	c.SetDecoderOptions(DecoderOptions{MaxBulkLen: 1 << 20})
+	if _, err := c.RawStream("get", "k"); err == nil {
+		t.Fatal("RawStream: expected LimitError")
+	}
+	if _, ok := c.Err().(LimitError); !ok {
+		t.Errorf("Err after RawStream: expected LimitError, got %#v", c.Err())
+	}
+}
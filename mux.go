@@ -0,0 +1,405 @@
+package redisb
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	ErrMuxClosed = errors.New("Mux closed")
+	// ErrMuxCommand is returned for commands that would block the connection
+	// or change its state for every other caller of a Mux. Such commands need
+	// a Conn of their own, for example from a Pool.
+	ErrMuxCommand = errors.New("Command can not be sent on a Mux")
+)
+
+var muxRejected = map[string]bool{
+	"blpop": true, "brpop": true, "brpoplpush": true, "blmove": true,
+	"blmpop": true, "bzpopmin": true, "bzpopmax": true, "bzmpop": true,
+	"wait": true, "waitaof": true,
+	"subscribe": true, "psubscribe": true, "ssubscribe": true,
+	"unsubscribe": true, "punsubscribe": true, "sunsubscribe": true,
+	"monitor": true, "multi": true, "exec": true, "discard": true,
+	"watch": true, "unwatch": true, "select": true, "hello": true,
+	"auth": true, "reset": true, "quit": true,
+}
+
+var muxRejectedClient = map[string]bool{"reply": true, "setname": true, "tracking": true}
+
+// muxRejects returns the name of the command in args if it can not be sent
+// on a Mux, and "" if it can. CLIENT is only rejected for the subcommands
+// that change the connection, and XREAD and XREADGROUP only with BLOCK.
+func muxRejects(args interface{}) string {
+	name := commandName(args)
+	switch {
+	case muxRejected[name]:
+		return name
+	case name == "client":
+		if sub := strings.ToLower(argAt(args, 1)); muxRejectedClient[sub] {
+			return name + " " + sub
+		}
+	case name == "xread" || name == "xreadgroup":
+		i := 1
+		if name == "xreadgroup" {
+			i = 4 // after GROUP group consumer
+		}
+		for ; ; i++ {
+			switch strings.ToLower(argAt(args, i)) {
+			case "block":
+				return name + " block"
+			case "streams", "":
+				return ""
+			}
+		}
+	}
+	return ""
+}
+
+// Mux shares one Conn between any number of goroutines. Commands are written
+// in the order they are called, and a single goroutine reads the replies and
+// hands each one to the caller waiting on it.
+//
+// Once the Conn breaks, every pending and later call fails with the error
+// that broke it. A Mux does not reconnect.
+type Mux struct {
+	c    *Conn
+	opts MuxOptions
+
+	wmu     sync.Mutex // serializes writes, and so the order of queue
+	queue   chan *muxCall
+	pending []*muxCall // written to c but not flushed yet
+	kick    chan struct{}
+	closed  bool
+
+	mu    sync.Mutex // guards err and stats
+	err   error
+	stats MuxStats
+
+	done chan struct{}
+}
+
+// MuxOptions makes a Mux pipeline the commands of concurrent callers
+// automatically. Instead of being written on its own, a command waits up to
+// Window for others to join it, and the batch is written at once when the
+// window ends or MaxBatch commands are waiting, whichever comes first.
+// Without a Window every command is written right away.
+type MuxOptions struct {
+	MaxBatch int
+	Window   time.Duration
+}
+
+// MuxStats counts the commands a Mux has written, and how long callers
+// waited for replies.
+type MuxStats struct {
+	Commands int64
+	Batches  int64 // writes, each of one or more commands
+	MaxBatch int   // most commands written at once
+
+	Replies   int64
+	TotalWait time.Duration // from each call to its reply, summed over Replies
+	MaxWait   time.Duration
+}
+
+type muxCall struct {
+	r       Reply
+	err     error
+	start   time.Time
+	flushed chan struct{} // closed once the command was flushed, or never will be
+	done    chan struct{}
+}
+
+// NewMux starts reading replies on c, which must not be used directly
+// afterwards.
+func NewMux(c *Conn) *Mux {
+	return NewMuxWithOptions(c, MuxOptions{})
+}
+
+// NewMuxWithOptions is NewMux with automatic pipelining as set by opts.
+func NewMuxWithOptions(c *Conn, opts MuxOptions) *Mux {
+	m := &Mux{c: c, opts: opts, queue: make(chan *muxCall, 256), done: make(chan struct{}), err: c.err}
+	go m.read()
+	if opts.Window > 0 {
+		m.kick = make(chan struct{}, 1)
+		go m.batch()
+	}
+	return m
+}
+
+// Stats returns a snapshot of the counters of m.
+func (m *Mux) Stats() MuxStats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.stats
+}
+
+// Close closes the Conn. Calls still waiting for replies fail with
+// ErrMuxClosed.
+func (m *Mux) Close() error {
+	m.wmu.Lock()
+	if m.closed {
+		m.wmu.Unlock()
+		return nil
+	}
+	m.closed = true
+	if m.kick != nil {
+		close(m.kick)
+	}
+	var err error
+	if m.fail(ErrMuxClosed) == ErrMuxClosed {
+		err = m.c.Close()
+	}
+	m.releaseLocked()
+	close(m.queue)
+	m.wmu.Unlock()
+	<-m.done
+	return err
+}
+
+// Err returns the error that left m unusable, if any.
+func (m *Mux) Err() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.err
+}
+
+// fail leaves m unusable because of err unless it already is, and returns
+// the error that did. Failures other than Close also close the Conn, so the
+// reader is not left waiting for replies that will never come.
+func (m *Mux) fail(err error) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.err == nil {
+		m.err = err
+		if err != ErrMuxClosed {
+			m.c.Close()
+		}
+	}
+	return m.err
+}
+
+func (m *Mux) read() {
+	defer close(m.done)
+	for call := range m.queue {
+		// the read timeout only starts once the command is on its way
+		<-call.flushed
+		if err := m.Err(); err != nil {
+			call.err = err
+		} else {
+			m.c.setDeadline(m.c.readTimeout, deadliner.SetReadDeadline)
+			call.r, call.err = m.c.decoder(false).reply()
+			if call.err != nil {
+				call.err = m.fail(timeoutError(call.err))
+			} else {
+				m.c.touch()
+			}
+		}
+		m.mu.Lock()
+		wait := time.Since(call.start)
+		m.stats.Replies++
+		m.stats.TotalWait += wait
+		if wait > m.stats.MaxWait {
+			m.stats.MaxWait = wait
+		}
+		m.mu.Unlock()
+		close(call.done)
+	}
+}
+
+// batch flushes each batch once its window has passed.
+func (m *Mux) batch() {
+	for range m.kick {
+		time.Sleep(m.opts.Window)
+		m.wmu.Lock()
+		m.flushLocked()
+		m.wmu.Unlock()
+	}
+}
+
+func (m *Mux) flushLocked() {
+	n := len(m.pending)
+	if n == 0 {
+		return
+	}
+	m.c.setDeadline(m.c.writeTimeout, deadliner.SetWriteDeadline)
+	err := m.c.w.Flush()
+	m.releaseLocked()
+	if err != nil {
+		m.fail(timeoutError(err))
+		return
+	}
+	m.c.touch()
+	m.mu.Lock()
+	m.stats.Commands += int64(n)
+	m.stats.Batches++
+	if n > m.stats.MaxBatch {
+		m.stats.MaxBatch = n
+	}
+	m.mu.Unlock()
+}
+
+// releaseLocked lets the reader go on to the pending commands, once they are
+// flushed or m failed.
+func (m *Mux) releaseLocked() {
+	for _, call := range m.pending {
+		close(call.flushed)
+	}
+	m.pending = nil
+}
+
+func (m *Mux) send(args interface{}) (*muxCall, error) {
+	if name := muxRejects(args); name != "" {
+		return nil, fmt.Errorf("%w: %s", ErrMuxCommand, name)
+	}
+	m.wmu.Lock()
+	defer m.wmu.Unlock()
+	if err := m.Err(); err != nil {
+		return nil, err
+	}
+	if err := m.c.w.appendArgs(args); err != nil {
+		return nil, err
+	}
+	call := &muxCall{start: time.Now(), flushed: make(chan struct{}), done: make(chan struct{})}
+	if len(m.queue) == cap(m.queue) {
+		// the reader may be waiting on a reply still in the buffer
+		m.flushLocked()
+	}
+	m.queue <- call
+	m.pending = append(m.pending, call)
+	switch {
+	case m.kick == nil || m.opts.MaxBatch > 0 && len(m.pending) >= m.opts.MaxBatch:
+		m.flushLocked()
+	case len(m.pending) == 1:
+		select {
+		case m.kick <- struct{}{}:
+		default:
+		}
+	}
+	return call, nil
+}
+
+func (m *Mux) do(args interface{}, bytes bool) (interface{}, error) {
+	call, err := m.send(args)
+	if err != nil {
+		return nil, err
+	}
+	<-call.done
+	if call.err != nil {
+		return nil, call.err
+	}
+	return call.r.value(bytes)
+}
+
+// Do sends a command whose arguments may be of any type Args accepts.
+func (m *Mux) Do(args ...interface{}) (interface{}, error) { return m.do(Args(args), false) }
+
+// Reply sends a command and returns its reply with the RESP type intact.
+func (m *Mux) Reply(args ...string) (Reply, error) {
+	call, err := m.send(args)
+	if err != nil {
+		return Reply{}, err
+	}
+	<-call.done
+	return call.r, call.err
+}
+
+func (m *Mux) Raw(args ...string) (interface{}, error) { return m.do(args, false) }
+
+func (m *Mux) Int64(args ...string) (int64, error) {
+	i, err := m.do(args, false)
+	if err != nil {
+		return 0, err
+	}
+	return toInt64(i)
+}
+
+func (m *Mux) Bool(args ...string) (bool, error) {
+	i, err := m.do(args, false)
+	if err != nil {
+		return false, err
+	}
+	return toBool(i)
+}
+
+func (m *Mux) String(args ...string) (string, error) {
+	i, err := m.do(args, false)
+	if err != nil {
+		return "", err
+	}
+	return toString(i)
+}
+
+func (m *Mux) Float64(args ...string) (float64, error) {
+	i, err := m.do(args, false)
+	if err != nil {
+		return 0, err
+	}
+	return toFloat64(i)
+}
+
+func (m *Mux) BigInt(args ...string) (*big.Int, error) {
+	i, err := m.do(args, false)
+	if err != nil {
+		return nil, err
+	}
+	return toBigInt(i)
+}
+
+func (m *Mux) Map(args ...string) (map[string]interface{}, error) {
+	i, err := m.do(args, false)
+	if err != nil {
+		return nil, err
+	}
+	return toMap(i)
+}
+
+func (m *Mux) Array(args ...string) ([]interface{}, error) {
+	i, err := m.do(args, false)
+	if err != nil {
+		return nil, err
+	}
+	return toArray(i)
+}
+
+func (m *Mux) Bools(args ...string) ([]bool, error) {
+	i, err := m.do(args, false)
+	if err != nil {
+		return nil, err
+	}
+	return toBools(i)
+}
+
+func (m *Mux) Int64s(args ...string) ([]int64, error) {
+	i, err := m.do(args, false)
+	if err != nil {
+		return nil, err
+	}
+	return toInt64s(i)
+}
+
+func (m *Mux) Strings(args ...string) ([]string, error) {
+	i, err := m.do(args, false)
+	if err != nil {
+		return nil, err
+	}
+	return toStrings(i)
+}
+
+func (m *Mux) Bytes(args ...string) ([]byte, error) {
+	i, err := m.do(args, true)
+	if err != nil {
+		return nil, err
+	}
+	return toBytes(i)
+}
+
+func (m *Mux) BytesSlice(args ...string) ([][]byte, error) {
+	i, err := m.do(args, true)
+	if err != nil {
+		return nil, err
+	}
+	return toBytesSlice(i)
+}
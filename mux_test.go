@@ -0,0 +1,139 @@
+package redisb
+
+import (
+	"errors"
+	"net"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestMux(t *testing.T) {
+	addr := listenServer(t, func(cmd []string) string {
+		if cmd[0] == "fail" {
+			return "-ERR failed\r\n"
+		}
+		return "$" + strconv.Itoa(len(cmd[1])) + "\r\n" + cmd[1] + "\r\n"
+	})
+	c, err := Dial("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := NewMux(c)
+	defer m.Close()
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				want := strconv.Itoa(g*1000 + i)
+				if s, err := m.String("echo", want); err != nil || s != want {
+					t.Errorf("echo %s: %q, %v", want, s, err)
+					return
+				}
+				if _, err := m.Raw("fail", want); err == nil {
+					t.Errorf("fail %s: expected RedisError", want)
+					return
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+	if m.Err() != nil {
+		t.Errorf("Err: %v", m.Err())
+	}
+}
+
+func TestMuxRejects(t *testing.T) {
+	m := NewMux(fakeServer(t, func(cmd []string) string { return "+OK\r\n" }))
+	defer m.Close()
+	for _, cmd := range []string{"blpop", "SUBSCRIBE", "multi", "select"} {
+		if _, err := m.Raw(cmd, "x"); !errors.Is(err, ErrMuxCommand) {
+			t.Errorf("%s: %v", cmd, err)
+		}
+	}
+	rejected := [][]string{
+		{"client", "SETNAME", "x"},
+		{"client", "reply", "off"},
+		{"xread", "count", "1", "block", "0", "streams", "s", "$"},
+		{"XREADGROUP", "GROUP", "g", "c", "BLOCK", "0", "STREAMS", "s", ">"},
+	}
+	for _, args := range rejected {
+		if _, err := m.Raw(args...); !errors.Is(err, ErrMuxCommand) {
+			t.Errorf("%q: %v", args, err)
+		}
+	}
+	allowed := [][]string{
+		{"client", "id"},
+		{"xread", "count", "1", "streams", "block", "0"},
+		{"xreadgroup", "group", "block", "c", "streams", "s", ">"},
+	}
+	for _, args := range allowed {
+		if _, err := m.Raw(args...); err != nil {
+			t.Errorf("%q: %v", args, err)
+		}
+	}
+	if ok, err := m.Bool("set", "k", "v"); err != nil || !ok {
+		t.Errorf("set: %v, %v", ok, err)
+	}
+}
+
+func TestMuxBroken(t *testing.T) {
+	m := NewMux(fakeServer(t, func(cmd []string) string { return "?\r\n" }))
+	_, err := m.Raw("get", "k")
+	if _, ok := err.(ProtocolError); !ok {
+		t.Fatalf("get: %#v", err)
+	}
+	if _, err2 := m.Raw("get", "k"); err2 != err || m.Err() != err {
+		t.Errorf("get after protocol error: %v", err2)
+	}
+	m.Close()
+	if err := m.Close(); err != nil {
+		t.Errorf("second Close: %v", err)
+	}
+}
+
+func TestMuxBatching(t *testing.T) {
+	client, server := net.Pipe()
+	defer server.Close()
+	go serve(server, func(cmd []string) string {
+		return ":" + cmd[1] + "\r\n"
+	})
+	cc := &countingConn{Conn: client}
+	m := NewMuxWithOptions(NewConn(cc), MuxOptions{MaxBatch: 10, Window: 20 * time.Millisecond})
+	defer m.Close()
+	var wg sync.WaitGroup
+	for i := 0; i < 25; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if n, err := m.Int64("echo", strconv.Itoa(i)); err != nil || n != int64(i) {
+				t.Errorf("echo %d: %d, %v", i, n, err)
+			}
+		}(i)
+	}
+	wg.Wait()
+	s := m.Stats()
+	if s.Commands != 25 || s.Replies != 25 || s.Batches != int64(atomic.LoadInt32(&cc.writes)) {
+		t.Errorf("Stats: %+v, %d writes", s, cc.writes)
+	}
+	if s.Batches > 5 || s.MaxBatch > 10 || s.MaxWait <= 0 || s.TotalWait < s.MaxWait {
+		t.Errorf("Stats: %+v", s)
+	}
+}
+
+func TestMuxWindowWithinReadTimeout(t *testing.T) {
+	c := fakeServer(t, func(cmd []string) string { return "+OK\r\n" })
+	c.SetReadTimeout(30 * time.Millisecond)
+	m := NewMuxWithOptions(c, MuxOptions{Window: 50 * time.Millisecond})
+	defer m.Close()
+	if ok, err := m.Bool("set", "k", "v"); err != nil || !ok {
+		t.Errorf("set: %v, %v", ok, err)
+	}
+	if m.Err() != nil {
+		t.Errorf("Err: %v", m.Err())
+	}
+}
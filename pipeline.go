@@ -0,0 +1,67 @@
+package redisb
+
+import "context"
+
+// Pipeline queues commands on a Conn and sends them all at once with Exec,
+// each returning a result to read after Exec.
+//
+//	p := c.Pipeline()
+//	n := p.Int64("incr", "hits")
+//	s := p.String("get", "name")
+//	err := p.Exec()
+//	hits, err := n.Result()
+type Pipeline struct {
+	queue
+	c *Conn
+}
+
+func (c *Conn) Pipeline() *Pipeline {
+	return &Pipeline{c: c}
+}
+
+// Exec writes every queued command in a single write and reads their replies
+// in order. A command whose reply is an error reply gets it as its RedisError
+// without affecting the others. Exec only returns an error if the Conn
+// failed, in which case the commands not yet answered get that error too.
+//
+// The Pipeline is empty again afterwards.
+func (p *Pipeline) Exec() error {
+	return p.c.exec(p.take())
+}
+
+// ExecContext is Exec with the deadline and cancellation of ctx applied.
+func (p *Pipeline) ExecContext(ctx context.Context) error {
+	results := p.take()
+	return p.c.withContext(ctx, func() error {
+		return p.c.exec(results)
+	})
+}
+
+func (c *Conn) exec(results []*result) error {
+	if err := c.ready(); err != nil {
+		for _, r := range results {
+			r.set(Reply{}, err)
+		}
+		return err
+	}
+	var sent []*result
+	for _, r := range results {
+		if err := c.w.appendArgs(r.args); err != nil {
+			r.set(Reply{}, err)
+			continue
+		}
+		c.note(r.args)
+		sent = append(sent, r)
+	}
+	err := c.flush()
+	for _, r := range sent {
+		if err == nil {
+			var reply Reply
+			reply, err = c.receiveReply()
+			r.set(reply, err)
+			continue
+		}
+		r.set(Reply{}, err)
+	}
+	return err
+}
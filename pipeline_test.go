@@ -0,0 +1,101 @@
+package redisb
+
+import (
+	"net"
+	"reflect"
+	"sync/atomic"
+	"testing"
+)
+
+type countingConn struct {
+	net.Conn
+	writes int32
+}
+
+func (cc *countingConn) Write(p []byte) (int, error) {
+	atomic.AddInt32(&cc.writes, 1)
+	return cc.Conn.Write(p)
+}
+
+func TestPipeline(t *testing.T) {
+	client, server := net.Pipe()
+	defer client.Close()
+	defer server.Close()
+	go serve(server, func(cmd []string) string {
+		switch cmd[0] {
+		case "incr":
+			return ":1\r\n"
+		case "get":
+			return "$1\r\nv\r\n"
+		case "lrange":
+			return "*2\r\n$1\r\na\r\n$1\r\nb\r\n"
+		}
+		return "-ERR unknown command '" + cmd[0] + "'\r\n"
+	})
+	cc := &countingConn{Conn: client}
+	c := NewConn(cc)
+	p := c.Pipeline()
+	n := p.Int64("incr", "k")
+	bad := p.String("nope")
+	s := p.String("get", "k")
+	l := p.Strings("lrange", "l", "0", "-1")
+	unsendable := p.Do("set", "k", struct{}{})
+	if _, err := n.Result(); err != ErrNotExecuted {
+		t.Errorf("Result before Exec: %v", err)
+	}
+	if p.Len() != 5 {
+		t.Errorf("Len: %d", p.Len())
+	}
+	if err := p.Exec(); err != nil {
+		t.Fatal(err)
+	}
+	if cc.writes != 1 {
+		t.Errorf("Exec wrote %d times", cc.writes)
+	}
+	if i, err := n.Result(); err != nil || i != 1 {
+		t.Errorf("incr: %d, %v", i, err)
+	}
+	if _, err := bad.Result(); err == nil {
+		t.Error("nope: expected RedisError")
+	} else if _, ok := err.(RedisError); !ok {
+		t.Errorf("nope: %#v", err)
+	}
+	if v, err := s.Result(); err != nil || v != "v" {
+		t.Errorf("get: %q, %v", v, err)
+	}
+	if v, err := l.Result(); err != nil || !reflect.DeepEqual(v, []string{"a", "b"}) {
+		t.Errorf("lrange: %q, %v", v, err)
+	}
+	if _, err := unsendable.Result(); err == nil {
+		t.Error("set: expected error for unsupported argument")
+	}
+	if p.Len() != 0 {
+		t.Errorf("Len after Exec: %d", p.Len())
+	}
+	if s, err := c.String("get", "k"); err != nil || s != "v" {
+		t.Errorf("get after Exec: %q, %v", s, err)
+	}
+}
+
+func TestPipelineBroken(t *testing.T) {
+	c := fakeServer(t, func(cmd []string) string {
+		if cmd[0] == "bad" {
+			return "?\r\n"
+		}
+		return "+OK\r\n"
+	})
+	p := c.Pipeline()
+	ok := p.Bool("set", "k", "v")
+	bad := p.Raw("bad")
+	after := p.Bool("set", "k", "v")
+	err := p.Exec()
+	if _, isProtocol := err.(ProtocolError); !isProtocol {
+		t.Fatalf("Exec: %#v", err)
+	}
+	if v, err := ok.Result(); err != nil || !v {
+		t.Errorf("set before failure: %v, %v", v, err)
+	}
+	if bad.Err() != err || after.Err() != err {
+		t.Errorf("results after failure: %v, %v", bad.Err(), after.Err())
+	}
+}
@@ -0,0 +1,323 @@
+package redisb
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+)
+
+var (
+	ErrPoolExhausted = errors.New("Connection pool exhausted")
+	ErrPoolClosed    = errors.New("Connection pool closed")
+	// ErrNotFromPool is returned by Put for a Conn that is not checked out
+	// from the Pool, such as one already handed back.
+	ErrNotFromPool = errors.New("Connection not checked out from this pool")
+)
+
+// Pool keeps idle connections for reuse. Connections are taken with Get or
+// GetContext and must be handed back with Put once the caller is done with
+// them, whatever state they are in: Put closes any Conn whose Err is set, or
+// that is left in a transaction, WATCHing keys, subscribed or on a database
+// chosen with SELECT, instead of keeping it.
+type Pool struct {
+	// Dial creates a new connection, for example with Dial.
+	Dial func() (*Conn, error)
+
+	// MaxIdle is the most idle connections kept. Zero keeps none.
+	MaxIdle int
+	// MaxActive is the most connections open at once, idle or not. Zero means
+	// no limit.
+	MaxActive int
+	// IdleTimeout closes connections left idle for longer. Zero means no
+	// limit.
+	IdleTimeout time.Duration
+	// MaxConnLifetime closes connections older than this instead of reusing
+	// them. Zero means no limit.
+	MaxConnLifetime time.Duration
+	// Wait makes Get wait for a connection to be handed back when MaxActive
+	// is reached, instead of failing with ErrPoolExhausted.
+	Wait bool
+	// TestOnBorrow PINGs idle connections before handing them out, and
+	// closes those that fail.
+	TestOnBorrow bool
+	// HealthCheckInterval makes the pool PING, in the background, idle
+	// connections that have not been used for this long, and close those that
+	// fail or take longer than the interval to answer. Zero disables it.
+	HealthCheckInterval time.Duration
+
+	mu     sync.Mutex
+	idle   []idleConn // most recently used first
+	active int
+	closed bool
+	waitc  chan struct{}
+	stats  PoolStats
+	stop   chan struct{} // closed by Close to end the health checker
+}
+
+type idleConn struct {
+	c *Conn
+	t time.Time
+}
+
+// PoolStats is a snapshot of the state and history of a Pool.
+type PoolStats struct {
+	ActiveCount int // connections open, idle or not
+	IdleCount   int
+
+	Hits        int64 // Gets served by an idle connection
+	Misses      int64 // Gets that dialed a new connection
+	Timeouts    int64 // Gets that gave up waiting for a connection
+	StaleCloses int64 // idle connections closed for age, idle time or a failed PING
+}
+
+func (p *Pool) Get() (*Conn, error) {
+	return p.GetContext(context.Background())
+}
+
+// GetContext is Get, except that waiting for a connection, or for a new one
+// to be dialed, ends with ctx.Err() once ctx is done.
+func (p *Pool) GetContext(ctx context.Context) (*Conn, error) {
+	p.mu.Lock()
+	for {
+		if p.closed {
+			p.mu.Unlock()
+			return nil, ErrPoolClosed
+		}
+		if p.HealthCheckInterval > 0 && p.stop == nil {
+			p.stop = make(chan struct{})
+			go p.healthCheck(p.HealthCheckInterval, p.stop)
+		}
+		p.pruneLocked(time.Now())
+		for len(p.idle) > 0 {
+			ic := p.idle[0]
+			p.idle = p.idle[1:]
+			if p.TestOnBorrow {
+				p.mu.Unlock()
+				err := ic.c.Ping()
+				p.mu.Lock()
+				if err != nil {
+					p.closeLocked(ic.c)
+					p.stats.StaleCloses++
+					continue
+				}
+			}
+			p.stats.Hits++
+			ic.c.pool = p
+			p.mu.Unlock()
+			return ic.c, nil
+		}
+		if p.MaxActive == 0 || p.active < p.MaxActive {
+			p.active++
+			p.stats.Misses++
+			p.mu.Unlock()
+			return p.dial(ctx)
+		}
+		if !p.Wait {
+			p.mu.Unlock()
+			return nil, ErrPoolExhausted
+		}
+		if p.waitc == nil {
+			p.waitc = make(chan struct{})
+		}
+		waitc := p.waitc
+		p.mu.Unlock()
+		select {
+		case <-waitc:
+		case <-ctx.Done():
+			p.mu.Lock()
+			p.stats.Timeouts++
+			p.mu.Unlock()
+			return nil, ctx.Err()
+		}
+		p.mu.Lock()
+	}
+}
+
+type dialResult struct {
+	c   *Conn
+	err error
+}
+
+// dial runs Dial for a Get that already counted the new connection as
+// active. A Conn dialed after ctx is done is closed.
+func (p *Pool) dial(ctx context.Context) (*Conn, error) {
+	dialed := make(chan dialResult, 1)
+	go func() {
+		c, err := p.Dial()
+		dialed <- dialResult{c, err}
+	}()
+	var dr dialResult
+	select {
+	case dr = <-dialed:
+	case <-ctx.Done():
+		go func() {
+			if dr := <-dialed; dr.err == nil {
+				dr.c.Close()
+			}
+			p.mu.Lock()
+			p.active--
+			p.notifyLocked()
+			p.mu.Unlock()
+		}()
+		p.mu.Lock()
+		p.stats.Timeouts++
+		p.mu.Unlock()
+		return nil, ctx.Err()
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if dr.err != nil {
+		p.active--
+		p.notifyLocked()
+		return nil, dr.err
+	}
+	dr.c.pool = p
+	return dr.c, nil
+}
+
+// Put hands c back to the pool. It returns ErrNotFromPool, and leaves c
+// alone, if c was not checked out from p.
+func (p *Pool) Put(c *Conn) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if c.pool != p {
+		return ErrNotFromPool
+	}
+	c.pool = nil
+	if p.closed || c.Err() != nil || c.stream != nil || c.state.dirty() || p.MaxIdle == 0 {
+		p.closeLocked(c)
+		return nil
+	}
+	p.idle = append([]idleConn{{c, time.Now()}}, p.idle...)
+	if len(p.idle) > p.MaxIdle {
+		p.closeLocked(p.idle[len(p.idle)-1].c)
+		p.idle = p.idle[:len(p.idle)-1]
+	}
+	p.notifyLocked()
+	return nil
+}
+
+func (p *Pool) Stats() PoolStats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	stats := p.stats
+	stats.ActiveCount = p.active
+	stats.IdleCount = len(p.idle)
+	return stats
+}
+
+// Close closes the idle connections and makes every later Get fail.
+// Connections handed back afterwards are closed by Put.
+func (p *Pool) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.closed = true
+	if p.stop != nil {
+		close(p.stop)
+	}
+	for _, ic := range p.idle {
+		p.closeLocked(ic.c)
+	}
+	p.idle = nil
+	p.notifyLocked()
+	return nil
+}
+
+func (p *Pool) healthCheck(interval time.Duration, stop chan struct{}) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case now := <-t.C:
+			p.checkIdle(now, interval)
+		}
+	}
+}
+
+// checkIdle PINGs the idle connections unused for interval, which are taken
+// out of the pool meanwhile so Get can not hand them out.
+func (p *Pool) checkIdle(now time.Time, interval time.Duration) {
+	p.mu.Lock()
+	p.pruneLocked(now)
+	var checked []idleConn
+	kept := p.idle[:0]
+	for _, ic := range p.idle {
+		if now.Sub(ic.c.LastActivity()) >= interval {
+			checked = append(checked, ic)
+			continue
+		}
+		kept = append(kept, ic)
+	}
+	p.idle = kept
+	p.mu.Unlock()
+	for _, ic := range checked {
+		ctx, cancel := context.WithTimeout(context.Background(), interval)
+		err := ic.c.withContext(ctx, ic.c.Ping)
+		cancel()
+		p.mu.Lock()
+		if err != nil || p.closed || len(p.idle) >= p.MaxIdle {
+			p.closeLocked(ic.c)
+			if err != nil {
+				p.stats.StaleCloses++
+			}
+		} else {
+			i := sort.Search(len(p.idle), func(i int) bool { return p.idle[i].t.Before(ic.t) })
+			p.idle = append(p.idle, idleConn{})
+			copy(p.idle[i+1:], p.idle[i:])
+			p.idle[i] = ic
+			p.notifyLocked()
+		}
+		p.mu.Unlock()
+	}
+}
+
+// pruneLocked closes idle connections past IdleTimeout or MaxConnLifetime,
+// starting from the least recently used.
+func (p *Pool) pruneLocked(now time.Time) {
+	for len(p.idle) > 0 {
+		ic := p.idle[len(p.idle)-1]
+		if !p.staleLocked(ic, now) {
+			break
+		}
+		p.idle = p.idle[:len(p.idle)-1]
+		p.closeLocked(ic.c)
+		p.stats.StaleCloses++
+	}
+	if p.MaxConnLifetime > 0 {
+		kept := p.idle[:0]
+		for _, ic := range p.idle {
+			if p.staleLocked(ic, now) {
+				p.closeLocked(ic.c)
+				p.stats.StaleCloses++
+				continue
+			}
+			kept = append(kept, ic)
+		}
+		p.idle = kept
+	}
+}
+
+func (p *Pool) staleLocked(ic idleConn, now time.Time) bool {
+	if p.IdleTimeout > 0 && now.Sub(ic.t) > p.IdleTimeout {
+		return true
+	}
+	return p.MaxConnLifetime > 0 && now.Sub(ic.c.created) > p.MaxConnLifetime
+}
+
+func (p *Pool) closeLocked(c *Conn) {
+	c.Close()
+	p.active--
+	p.notifyLocked()
+}
+
+// notifyLocked wakes every Get waiting for a connection.
+func (p *Pool) notifyLocked() {
+	if p.waitc != nil {
+		close(p.waitc)
+		p.waitc = nil
+	}
+}
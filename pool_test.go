@@ -0,0 +1,182 @@
+package redisb
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func testPool(t *testing.T, p *Pool) *int32 {
+	var dials int32
+	addr := listenServer(t, func(cmd []string) string {
+		switch cmd[0] {
+		case "ping":
+			return "+PONG\r\n"
+		case "bad":
+			return "?\r\n"
+		}
+		return "+OK\r\n"
+	})
+	p.Dial = func() (*Conn, error) {
+		atomic.AddInt32(&dials, 1)
+		return Dial("tcp", addr)
+	}
+	t.Cleanup(func() { p.Close() })
+	return &dials
+}
+
+func TestPoolReuse(t *testing.T) {
+	p := &Pool{MaxIdle: 1, TestOnBorrow: true}
+	dials := testPool(t, p)
+	c, err := p.Get()
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.Put(c)
+	c, err = p.Get()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Raw("bad"); err == nil {
+		t.Fatal("Raw: expected ProtocolError")
+	}
+	p.Put(c)
+	if _, err := p.Get(); err != nil {
+		t.Fatal(err)
+	}
+	s := p.Stats()
+	if s.Hits != 1 || s.Misses != 2 || s.ActiveCount != 1 || s.IdleCount != 0 || atomic.LoadInt32(dials) != 2 {
+		t.Errorf("Stats: %+v, dials: %d", s, atomic.LoadInt32(dials))
+	}
+}
+
+func TestPoolExhausted(t *testing.T) {
+	p := &Pool{MaxIdle: 1, MaxActive: 1}
+	testPool(t, p)
+	c, err := p.Get()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.Get(); err != ErrPoolExhausted {
+		t.Errorf("Get: expected ErrPoolExhausted, got %v", err)
+	}
+	p.Wait = true
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := p.GetContext(ctx); err != context.DeadlineExceeded {
+		t.Errorf("GetContext: expected context.DeadlineExceeded, got %v", err)
+	}
+	time.AfterFunc(20*time.Millisecond, func() { p.Put(c) })
+	if got, err := p.Get(); err != nil || got != c {
+		t.Errorf("Get: expected the Conn handed back, got %p, %v", got, err)
+	}
+	if s := p.Stats(); s.Timeouts != 1 || s.Hits != 1 {
+		t.Errorf("Stats: %+v", s)
+	}
+}
+
+func TestPoolStale(t *testing.T) {
+	p := &Pool{MaxIdle: 2, IdleTimeout: 10 * time.Millisecond}
+	dials := testPool(t, p)
+	c, _ := p.Get()
+	p.Put(c)
+	time.Sleep(20 * time.Millisecond)
+	if _, err := p.Get(); err != nil {
+		t.Fatal(err)
+	}
+	if s := p.Stats(); s.StaleCloses != 1 || s.Misses != 2 || atomic.LoadInt32(dials) != 2 {
+		t.Errorf("Stats: %+v", s)
+	}
+}
+
+func TestPoolPut(t *testing.T) {
+	p := &Pool{MaxIdle: 2}
+	testPool(t, p)
+	c, _ := p.Get()
+	if err := p.Put(c); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Put(c); err != ErrNotFromPool {
+		t.Errorf("Put twice: expected ErrNotFromPool, got %v", err)
+	}
+	other := &Pool{MaxIdle: 2}
+	testPool(t, other)
+	foreign, _ := other.Get()
+	if err := p.Put(foreign); err != ErrNotFromPool {
+		t.Errorf("Put from another Pool: expected ErrNotFromPool, got %v", err)
+	}
+	if s := p.Stats(); s.IdleCount != 1 || s.ActiveCount != 1 {
+		t.Errorf("Stats: %+v", s)
+	}
+	for _, cmd := range []string{"multi", "watch", "subscribe", "select"} {
+		c, _ := p.Get()
+		c.Raw(cmd, "x")
+		p.Put(c)
+		if s := p.Stats(); s.IdleCount != 0 || s.ActiveCount != 0 {
+			t.Errorf("Put after %s: Stats: %+v", cmd, s)
+		}
+	}
+}
+
+func TestPoolDialContext(t *testing.T) {
+	release := make(chan struct{})
+	p := &Pool{MaxIdle: 1}
+	p.Dial = func() (*Conn, error) {
+		<-release
+		return nil, errors.New("dial failed")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	done := make(chan error, 1)
+	go func() {
+		_, err := p.GetContext(ctx)
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err != context.DeadlineExceeded {
+			t.Errorf("GetContext: expected context.DeadlineExceeded, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("GetContext: waited for Dial after ctx was done")
+	}
+	close(release)
+	time.Sleep(10 * time.Millisecond)
+	if s := p.Stats(); s.ActiveCount != 0 || s.Timeouts != 1 {
+		t.Errorf("Stats: %+v", s)
+	}
+}
+
+func TestPoolHealthCheck(t *testing.T) {
+	var healthy atomic.Bool
+	healthy.Store(true)
+	addr := listenServer(t, func(cmd []string) string {
+		if !healthy.Load() {
+			return hangUp
+		}
+		return "+PONG\r\n"
+	})
+	p := &Pool{MaxIdle: 1, HealthCheckInterval: 10 * time.Millisecond}
+	p.Dial = func() (*Conn, error) { return Dial("tcp", addr) }
+	defer p.Close()
+	c, err := p.Get()
+	if err != nil {
+		t.Fatal(err)
+	}
+	before := c.LastActivity()
+	p.Put(c)
+	time.Sleep(50 * time.Millisecond)
+	if !c.LastActivity().After(before) {
+		t.Errorf("LastActivity not updated by health check: %v", c.LastActivity())
+	}
+	if s := p.Stats(); s.IdleCount != 1 || s.StaleCloses != 0 {
+		t.Errorf("Stats while healthy: %+v", s)
+	}
+	healthy.Store(false)
+	time.Sleep(50 * time.Millisecond)
+	if s := p.Stats(); s.IdleCount != 0 || s.ActiveCount != 0 || s.StaleCloses != 1 {
+		t.Errorf("Stats after failed PING: %+v", s)
+	}
+}
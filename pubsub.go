@@ -0,0 +1,295 @@
+package redisb
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+var ErrPubSubClosed = errors.New("PubSub closed")
+
+// Message is a message published to a channel subscribed to with Subscribe.
+type Message struct {
+	Channel string
+	Data    string
+}
+
+// PMessage is a message published to a channel matching a pattern subscribed
+// to with PSubscribe.
+type PMessage struct {
+	Pattern string
+	Channel string
+	Data    string
+}
+
+// Subscription confirms a subscribe, psubscribe, unsubscribe or punsubscribe,
+// given as Kind, and reports how many subscriptions are left.
+type Subscription struct {
+	Kind    string
+	Channel string
+	Count   int64
+}
+
+// Pong answers a PubSub.Ping.
+type Pong struct {
+	Data string
+}
+
+// Reconnected is delivered once a PubSub has reconnected after Err broke its
+// connection, and subscribed again to every channel and pattern.
+type Reconnected struct {
+	Err error
+}
+
+// PubSub receives messages on a Conn in subscribed state. Messages, PMessages,
+// Subscriptions, Pongs and Reconnected events are delivered, in the order
+// they happen, on the channel returned by Messages. So are error replies, as
+// RedisError values, and replies of no known form, as ConversionError values.
+//
+// If the connection breaks and the Conn came from Dial, PubSub reconnects
+// with growing backoff until it succeeds or is closed. Otherwise the error is
+// returned by Err and the channel is closed.
+type PubSub struct {
+	c    *Conn
+	msgs chan interface{}
+
+	wmu      sync.Mutex // serializes writes and guards everything below
+	channels map[string]bool
+	patterns map[string]bool
+	closed   bool
+	err      error
+
+	ctx    context.Context // done once Close is called
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewPubSub starts reading messages on c, which must not be used directly
+// afterwards.
+func NewPubSub(c *Conn) *PubSub {
+	ps := &PubSub{
+		c:        c,
+		msgs:     make(chan interface{}, 64),
+		channels: map[string]bool{},
+		patterns: map[string]bool{},
+		done:     make(chan struct{}),
+	}
+	ps.ctx, ps.cancel = context.WithCancel(context.Background())
+	go ps.read()
+	return ps
+}
+
+// Messages returns the channel messages are delivered on. It is closed once
+// the PubSub is closed or its connection broke for good.
+func (ps *PubSub) Messages() <-chan interface{} {
+	return ps.msgs
+}
+
+func (ps *PubSub) Subscribe(channels ...string) error {
+	return ps.subscribe("subscribe", ps.channels, true, channels)
+}
+
+func (ps *PubSub) PSubscribe(patterns ...string) error {
+	return ps.subscribe("psubscribe", ps.patterns, true, patterns)
+}
+
+// Unsubscribe unsubscribes from channels, or from every channel if none are
+// given.
+func (ps *PubSub) Unsubscribe(channels ...string) error {
+	return ps.subscribe("unsubscribe", ps.channels, false, channels)
+}
+
+// PUnsubscribe unsubscribes from patterns, or from every pattern if none are
+// given.
+func (ps *PubSub) PUnsubscribe(patterns ...string) error {
+	return ps.subscribe("punsubscribe", ps.patterns, false, patterns)
+}
+
+// Ping sends PING, answered with a Pong carrying data, if given.
+func (ps *PubSub) Ping(data ...string) error {
+	ps.wmu.Lock()
+	defer ps.wmu.Unlock()
+	return ps.sendLocked(prepend("ping", data))
+}
+
+func (ps *PubSub) subscribe(command string, set map[string]bool, add bool, names []string) error {
+	ps.wmu.Lock()
+	defer ps.wmu.Unlock()
+	if !add && len(names) == 0 {
+		for name := range set {
+			delete(set, name)
+		}
+	}
+	for _, name := range names {
+		if add {
+			set[name] = true
+		} else {
+			delete(set, name)
+		}
+	}
+	return ps.sendLocked(prepend(command, names))
+}
+
+// sendLocked writes a command. If that fails, the reader notices the broken
+// connection too, and reconnects if it can.
+func (ps *PubSub) sendLocked(args []string) error {
+	if ps.closed {
+		return ErrPubSubClosed
+	}
+	if ps.err != nil {
+		return ps.err
+	}
+	return ps.c.send(args)
+}
+
+// Close closes the connection and the channel returned by Messages.
+func (ps *PubSub) Close() error {
+	// interrupts a reconnect in progress, which holds wmu
+	ps.cancel()
+	ps.wmu.Lock()
+	if ps.closed {
+		ps.wmu.Unlock()
+		return nil
+	}
+	ps.closed = true
+	err := ps.c.Close()
+	ps.wmu.Unlock()
+	<-ps.done
+	return err
+}
+
+// Err returns the error that broke the connection for good, if any.
+func (ps *PubSub) Err() error {
+	ps.wmu.Lock()
+	defer ps.wmu.Unlock()
+	return ps.err
+}
+
+func (ps *PubSub) read() {
+	defer close(ps.done)
+	defer close(ps.msgs)
+	for {
+		ps.c.setDeadline(0, deadliner.SetReadDeadline)
+		r, err := ps.c.decoder(false).reply()
+		if err != nil {
+			if !ps.reconnect(err) {
+				return
+			}
+			continue
+		}
+		ps.c.touch()
+		if !ps.deliver(toPubSubMessage(r)) {
+			return
+		}
+	}
+}
+
+func (ps *PubSub) deliver(msg interface{}) bool {
+	select {
+	case ps.msgs <- msg:
+		return true
+	case <-ps.ctx.Done():
+		return false
+	}
+}
+
+// reconnect replaces the connection broken by err and subscribes again,
+// retrying with backoff. It reports false if the PubSub is done instead.
+// Close interrupts the dial and setup of the new connection.
+func (ps *PubSub) reconnect(err error) bool {
+	for backoff := 10 * time.Millisecond; ; backoff *= 2 {
+		ps.wmu.Lock()
+		if ps.closed {
+			ps.wmu.Unlock()
+			return false
+		}
+		ps.c.fail(err)
+		if ps.c.redial == nil {
+			ps.err = ps.c.err
+			ps.wmu.Unlock()
+			return false
+		}
+		rerr := ps.c.withContext(ps.ctx, func() error { return ps.c.reconnect(ps.ctx) })
+		if rerr == nil {
+			rerr = ps.resubscribeLocked()
+		}
+		ps.wmu.Unlock()
+		if rerr == nil {
+			return ps.deliver(Reconnected{timeoutError(err)})
+		}
+		if backoff > 5*time.Second {
+			backoff = 5 * time.Second
+		}
+		select {
+		case <-time.After(backoff):
+		case <-ps.ctx.Done():
+			return false
+		}
+	}
+}
+
+// resubscribeLocked subscribes again to the channels, then the patterns,
+// each in sorted order.
+func (ps *PubSub) resubscribeLocked() error {
+	for _, s := range []struct {
+		command string
+		set     map[string]bool
+	}{{"subscribe", ps.channels}, {"psubscribe", ps.patterns}} {
+		if len(s.set) == 0 {
+			continue
+		}
+		args := []string{s.command}
+		for name := range s.set {
+			args = append(args, name)
+		}
+		sort.Strings(args[1:])
+		if err := ps.c.send(args); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func toPubSubMessage(r Reply) interface{} {
+	if err := r.Err(); err != nil {
+		return err
+	}
+	// outside of RESP2 subscribed mode, PING is answered with +PONG, or with
+	// its argument as a Bulk String
+	switch s, _ := r.Str(); {
+	case r.Kind == KindSimpleString && s == "PONG":
+		return Pong{}
+	case r.Kind == KindBulk:
+		return Pong{s}
+	}
+	e := r.Elems()
+	if r.Kind != KindArray && r.Kind != KindPush || len(e) == 0 {
+		return newConversionError("Unexpected Pub/Sub reply: %#v", r)
+	}
+	kind, _ := e[0].Str()
+	str := func(i int) string {
+		if i >= len(e) {
+			return ""
+		}
+		s, _ := e[i].Str()
+		return s
+	}
+	switch kind = strings.ToLower(kind); kind {
+	case "message":
+		return Message{str(1), str(2)}
+	case "pmessage":
+		return PMessage{str(1), str(2), str(3)}
+	case "subscribe", "psubscribe", "unsubscribe", "punsubscribe":
+		var count int64
+		if len(e) > 2 {
+			count, _ = e[2].Int64()
+		}
+		return Subscription{kind, str(1), count}
+	case "pong":
+		return Pong{str(1)}
+	}
+	return newConversionError("Unexpected Pub/Sub reply: %#v", r)
+}
@@ -0,0 +1,203 @@
+package redisb
+
+import (
+	"reflect"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+// pubSubServer answers subscribe and psubscribe commands as Redis does, and
+// publishes a message to each channel right after subscribing to it.
+func pubSubServer(t *testing.T, mu *sync.Mutex, conns *int) string {
+	return listenServer(t, func(cmd []string) string {
+		mu.Lock()
+		defer mu.Unlock()
+		var reply string
+		switch cmd[0] {
+		case "subscribe":
+			*conns++
+			for i, ch := range cmd[1:] {
+				reply += "*3\r\n$9\r\nsubscribe\r\n$" + strconv.Itoa(len(ch)) + "\r\n" + ch + "\r\n:" + strconv.Itoa(i+1) + "\r\n"
+				reply += "*3\r\n$7\r\nmessage\r\n$" + strconv.Itoa(len(ch)) + "\r\n" + ch + "\r\n$2\r\nhi\r\n"
+			}
+		case "psubscribe":
+			reply = "*3\r\n$10\r\npsubscribe\r\n$2\r\nn*\r\n:2\r\n"
+			reply += ">4\r\n$8\r\npmessage\r\n$2\r\nn*\r\n$4\r\nnews\r\n$3\r\nnew\r\n"
+		case "ping":
+			reply = "*2\r\n$4\r\npong\r\n$2\r\nok\r\n"
+		case "drop":
+			return hangUp
+		}
+		return reply
+	})
+}
+
+func receive(t *testing.T, ps *PubSub) interface{} {
+	select {
+	case msg := <-ps.Messages():
+		return msg
+	case <-time.After(time.Second):
+		t.Fatal("no message received")
+	}
+	return nil
+}
+
+func TestPubSub(t *testing.T) {
+	var mu sync.Mutex
+	conns := 0
+	addr := pubSubServer(t, &mu, &conns)
+	c, err := Dial("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ps := NewPubSub(c)
+	defer ps.Close()
+	if err := ps.Subscribe("a"); err != nil {
+		t.Fatal(err)
+	}
+	if err := ps.PSubscribe("n*"); err != nil {
+		t.Fatal(err)
+	}
+	if err := ps.Ping("ok"); err != nil {
+		t.Fatal(err)
+	}
+	want := []interface{}{
+		Subscription{"subscribe", "a", 1},
+		Message{"a", "hi"},
+		Subscription{"psubscribe", "n*", 2},
+		PMessage{"n*", "news", "new"},
+		Pong{"ok"},
+	}
+	for _, w := range want {
+		if got := receive(t, ps); !reflect.DeepEqual(got, w) {
+			t.Errorf("received %#v, expected %#v", got, w)
+		}
+	}
+
+	ps.wmu.Lock()
+	ps.sendLocked([]string{"drop"})
+	ps.wmu.Unlock()
+	if got, ok := receive(t, ps).(Reconnected); !ok || got.Err == nil {
+		t.Fatalf("expected Reconnected, got %#v", got)
+	}
+	got := map[interface{}]bool{}
+	for i := 0; i < 4; i++ {
+		got[receive(t, ps)] = true
+	}
+	for _, w := range want[:4] {
+		if !got[w] {
+			t.Errorf("after reconnect, %#v not received", w)
+		}
+	}
+	mu.Lock()
+	if conns != 2 {
+		t.Errorf("subscribed on %d connections", conns)
+	}
+	mu.Unlock()
+
+	ps.Close()
+	if _, ok := <-ps.Messages(); ok {
+		t.Error("Messages not closed by Close")
+	}
+	if err := ps.Subscribe("b"); err != ErrPubSubClosed {
+		t.Errorf("Subscribe after Close: %v", err)
+	}
+}
+
+func TestPubSubBroken(t *testing.T) {
+	ps := NewPubSub(fakeServer(t, func(cmd []string) string { return hangUp }))
+	defer ps.Close()
+	ps.Subscribe("a")
+	if _, ok := <-ps.Messages(); ok {
+		t.Error("Messages not closed after connection broke")
+	}
+	if ps.Err() == nil {
+		t.Error("Err: expected error")
+	}
+}
+
+func TestPubSubResubscribeOrder(t *testing.T) {
+	var mu sync.Mutex
+	var sent [][]string
+	addr := listenServer(t, func(cmd []string) string {
+		mu.Lock()
+		defer mu.Unlock()
+		sent = append(sent, cmd)
+		switch cmd[0] {
+		case "drop":
+			return hangUp
+		case "ping":
+			return "$1\r\n" + cmd[1] + "\r\n"
+		}
+		return ""
+	})
+	c, err := Dial("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ps := NewPubSub(c)
+	defer ps.Close()
+	ps.PSubscribe("p*")
+	ps.Subscribe("b", "a", "c")
+	ps.Ping("x")
+	if got := receive(t, ps); got != (Pong{"x"}) {
+		t.Errorf("expected Pong with data, got %#v", got)
+	}
+	ps.wmu.Lock()
+	ps.sendLocked([]string{"drop"})
+	ps.wmu.Unlock()
+	if _, ok := receive(t, ps).(Reconnected); !ok {
+		t.Fatal("expected Reconnected")
+	}
+	ps.Ping("y")
+	if got := receive(t, ps); got != (Pong{"y"}) {
+		t.Errorf("expected Pong with data, got %#v", got)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	want := [][]string{{"subscribe", "a", "b", "c"}, {"psubscribe", "p*"}}
+	if got := sent[len(sent)-3 : len(sent)-1]; !reflect.DeepEqual(got, want) {
+		t.Errorf("resubscribed with %q", got)
+	}
+}
+
+func TestPubSubCloseWhileReconnecting(t *testing.T) {
+	var mu sync.Mutex
+	auths := 0
+	addr := listenServer(t, func(cmd []string) string {
+		mu.Lock()
+		defer mu.Unlock()
+		switch cmd[0] {
+		case "auth":
+			auths++
+			if auths > 1 {
+				return "" // the setup of the new connection never finishes
+			}
+			return "+OK\r\n"
+		case "drop":
+			return hangUp
+		}
+		return ""
+	})
+	c, err := Dial("tcp", addr, DialPassword("p"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ps := NewPubSub(c)
+	ps.wmu.Lock()
+	ps.sendLocked([]string{"drop"})
+	ps.wmu.Unlock()
+	time.Sleep(50 * time.Millisecond)
+	closed := make(chan struct{})
+	go func() {
+		ps.Close()
+		close(closed)
+	}()
+	select {
+	case <-closed:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Close blocked by a hung reconnect")
+	}
+}
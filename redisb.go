@@ -3,21 +3,37 @@ package redisb
 import (
 	"bufio"
 	"bytes"
+	"errors"
 	"fmt"
 	"io"
+	"math/big"
 	"reflect"
 	"strconv"
 	"strings"
 )
 
 func Raw(rw io.ReadWriter, args ...string) (interface{}, error) {
-	fmt.Fprint(rw, Encode(args))
-	return Decode(bufio.NewReader(rw))
+	return do(rw, args)
+}
+
+func do(rw io.ReadWriter, args interface{}) (interface{}, error) {
+	return connFor(rw).do(args, false)
+}
+
+func doBytes(rw io.ReadWriter, args interface{}) (interface{}, error) {
+	return connFor(rw).do(args, true)
+}
+
+func connFor(rw io.ReadWriter) *Conn {
+	c, ok := rw.(*Conn)
+	if !ok {
+		c = newConn(rw)
+	}
+	return c
 }
 
 func Int64(rw io.ReadWriter, args ...string) (int64, error) {
-	fmt.Fprint(rw, Encode(args))
-	i, err := Decode(bufio.NewReader(rw))
+	i, err := do(rw, args)
 	if err != nil {
 		return 0, err
 	}
@@ -35,13 +51,16 @@ func toInt64(i interface{}) (int64, error) {
 			return 0, newConversionError("Conversion to int64 failed: %#v, %s", i, err)
 		}
 		return result, nil
+	case *big.Int:
+		if t.IsInt64() {
+			return t.Int64(), nil
+		}
 	}
 	return 0, newConversionError("Conversion to int64 failed: %#v", i)
 }
 
 func Bool(rw io.ReadWriter, args ...string) (bool, error) {
-	fmt.Fprint(rw, Encode(args))
-	i, err := Decode(bufio.NewReader(rw))
+	i, err := do(rw, args)
 	if err != nil {
 		return false, err
 	}
@@ -61,6 +80,10 @@ func toBool(i interface{}) (bool, error) {
 		return false, nil
 	case int64(0):
 		return false, nil
+	case true:
+		return true, nil
+	case false:
+		return false, nil
 	case nil:
 		return false, nil
 	}
@@ -68,8 +91,7 @@ func toBool(i interface{}) (bool, error) {
 }
 
 func String(rw io.ReadWriter, args ...string) (string, error) {
-	fmt.Fprint(rw, Encode(args))
-	i, err := Decode(bufio.NewReader(rw))
+	i, err := do(rw, args)
 	if err != nil {
 		return "", err
 	}
@@ -83,18 +105,109 @@ func toString(i interface{}) (string, error) {
 		return s, nil
 	}
 	switch t := i.(type) {
+	case []byte:
+		return string(t), nil
 	case int64:
 		return strconv.FormatInt(t, 10), nil
+	case float64:
+		return strconv.FormatFloat(t, 'f', -1, 64), nil
+	case *big.Int:
+		return t.String(), nil
 	}
 	return "", newConversionError("Conversion to string failed: %#v %s", i, reflect.TypeOf(i))
 }
 
+func Float64(rw io.ReadWriter, args ...string) (float64, error) {
+	i, err := do(rw, args)
+	if err != nil {
+		return 0, err
+	}
+	result, err := toFloat64(i)
+	return result, err
+}
+
+func toFloat64(i interface{}) (float64, error) {
+	switch t := i.(type) {
+	case float64:
+		return t, nil
+	case int64:
+		return float64(t), nil
+	case string:
+		result, err := toFloat(t)
+		if err != nil {
+			return 0, newConversionError("Conversion to float64 failed: %#v, %s", i, err)
+		}
+		return result, nil
+	}
+	return 0, newConversionError("Conversion to float64 failed: %#v", i)
+}
+
+func BigInt(rw io.ReadWriter, args ...string) (*big.Int, error) {
+	i, err := do(rw, args)
+	if err != nil {
+		return nil, err
+	}
+	result, err := toBigInt(i)
+	return result, err
+}
+
+func toBigInt(i interface{}) (*big.Int, error) {
+	switch t := i.(type) {
+	case *big.Int:
+		return t, nil
+	case int64:
+		return big.NewInt(t), nil
+	case string:
+		result, ok := new(big.Int).SetString(strings.TrimSpace(t), 10)
+		if !ok {
+			return nil, newConversionError("Conversion to *big.Int failed: %#v", i)
+		}
+		return result, nil
+	}
+	return nil, newConversionError("Conversion to *big.Int failed: %#v", i)
+}
+
+func Map(rw io.ReadWriter, args ...string) (map[string]interface{}, error) {
+	i, err := do(rw, args)
+	if err != nil {
+		return nil, err
+	}
+	result, err := toMap(i)
+	return result, err
+}
+
+// toMap also accepts the flat key/value arrays RESP2 servers send for
+// replies that RESP3 servers send as maps.
+func toMap(i interface{}) (map[string]interface{}, error) {
+	switch t := i.(type) {
+	case map[string]interface{}:
+		return t, nil
+	case []interface{}:
+		if len(t)%2 != 0 {
+			return nil, newConversionError("Conversion to map[string]interface{} failed, odd length: %#v", i)
+		}
+		result := make(map[string]interface{}, len(t)/2)
+		for j := 0; j < len(t); j += 2 {
+			k, err := toString(t[j])
+			if err != nil {
+				return nil, newConversionError("Conversion to map[string]interface{} failed: %#v: %s", i, err)
+			}
+			result[k] = t[j+1]
+		}
+		return result, nil
+	}
+	return nil, newConversionError("Conversion to map[string]interface{} failed: %#v", i)
+}
+
 func Array(rw io.ReadWriter, args ...string) ([]interface{}, error) {
-	fmt.Fprint(rw, Encode(args))
-	i, err := Decode(bufio.NewReader(rw))
+	i, err := do(rw, args)
 	if err != nil {
 		return nil, err
 	}
+	return toArray(i)
+}
+
+func toArray(i interface{}) ([]interface{}, error) {
 	a, ok := i.([]interface{})
 	if ok {
 		return a, nil
@@ -103,17 +216,23 @@ func Array(rw io.ReadWriter, args ...string) ([]interface{}, error) {
 }
 
 func Bools(rw io.ReadWriter, args ...string) ([]bool, error) {
-	fmt.Fprint(rw, Encode(args))
-	i, err := Decode(bufio.NewReader(rw))
+	i, err := do(rw, args)
 	if err != nil {
 		return nil, err
 	}
+	return toBools(i)
+}
+
+func toBools(i interface{}) ([]bool, error) {
 	a, ok := i.([]interface{})
 	if !ok {
 		return nil, newConversionError("Conversion to []bool failed: %#v", i)
 	}
 	result := []bool{}
 	for _, v := range a {
+		if re, ok := v.(RedisError); ok {
+			return nil, re
+		}
 		sv, err := toBool(v)
 		if err != nil {
 			return nil, newConversionError("Conversion to []bool failed: %#v: %s", i, err)
@@ -124,17 +243,23 @@ func Bools(rw io.ReadWriter, args ...string) ([]bool, error) {
 }
 
 func Int64s(rw io.ReadWriter, args ...string) ([]int64, error) {
-	fmt.Fprint(rw, Encode(args))
-	i, err := Decode(bufio.NewReader(rw))
+	i, err := do(rw, args)
 	if err != nil {
 		return nil, err
 	}
+	return toInt64s(i)
+}
+
+func toInt64s(i interface{}) ([]int64, error) {
 	a, ok := i.([]interface{})
 	if !ok {
 		return nil, newConversionError("Conversion to []int64 failed: %#v", i)
 	}
 	result := []int64{}
 	for _, v := range a {
+		if re, ok := v.(RedisError); ok {
+			return nil, re
+		}
 		sv, err := toInt64(v)
 		if err != nil {
 			return nil, newConversionError("Conversion to []int64 failed: %#v: %s", i, err)
@@ -145,17 +270,23 @@ func Int64s(rw io.ReadWriter, args ...string) ([]int64, error) {
 }
 
 func Strings(rw io.ReadWriter, args ...string) ([]string, error) {
-	fmt.Fprint(rw, Encode(args))
-	i, err := Decode(bufio.NewReader(rw))
+	i, err := do(rw, args)
 	if err != nil {
 		return nil, err
 	}
+	return toStrings(i)
+}
+
+func toStrings(i interface{}) ([]string, error) {
 	a, ok := i.([]interface{})
 	if !ok {
 		return nil, newConversionError("Conversion to []string failed: %#v", i)
 	}
 	result := []string{}
 	for _, v := range a {
+		if re, ok := v.(RedisError); ok {
+			return nil, re
+		}
 		sv, err := toString(v)
 		if err != nil {
 			return nil, newConversionError("Conversion to []string failed: %#v: %s", i, err)
@@ -165,6 +296,25 @@ func Strings(rw io.ReadWriter, args ...string) ([]string, error) {
 	return result, nil
 }
 
+// Push is an out-of-band RESP3 push reply, such as a Pub/Sub message.
+type Push []interface{}
+
+// ElemErrors returns the error of each element of a, such as the reply to
+// EXEC or to a script, that is a RedisError, and nil for the others. It
+// returns nil if no element is an error.
+func ElemErrors(a []interface{}) []error {
+	var errs []error
+	for i, v := range a {
+		if re, ok := v.(RedisError); ok {
+			if errs == nil {
+				errs = make([]error, len(a))
+			}
+			errs[i] = re
+		}
+	}
+	return errs
+}
+
 type ReaderError struct {
 	e error
 }
@@ -173,10 +323,48 @@ func (re ReaderError) Error() string {
 	return re.e.Error()
 }
 
+func (re ReaderError) Unwrap() error {
+	return errors.Unwrap(re.e)
+}
+
 func newReaderError(format string, values ...interface{}) ReaderError {
 	return ReaderError{fmt.Errorf(format, values...)}
 }
 
+type WriterError struct {
+	e error
+}
+
+func (we WriterError) Error() string {
+	return we.e.Error()
+}
+
+func (we WriterError) Unwrap() error {
+	return errors.Unwrap(we.e)
+}
+
+func newWriterError(format string, values ...interface{}) WriterError {
+	return WriterError{fmt.Errorf(format, values...)}
+}
+
+// TimeoutError reports a read or write deadline expiring mid-call. The
+// Conn it came from can not be used again.
+type TimeoutError struct {
+	e error
+}
+
+func (te TimeoutError) Error() string {
+	return te.e.Error()
+}
+
+func (te TimeoutError) Unwrap() error {
+	return te.e
+}
+
+func (te TimeoutError) Timeout() bool {
+	return true
+}
+
 type ConversionError struct {
 	e error
 }
@@ -189,6 +377,22 @@ func newConversionError(format string, values ...interface{}) ConversionError {
 	return ConversionError{fmt.Errorf(format, values...)}
 }
 
+// ProtocolError reports bytes that do not follow RESP. Offset is the position
+// of Byte within the reply being decoded, or of the argument being encoded.
+type ProtocolError struct {
+	Byte   byte
+	Offset int64
+	e      error
+}
+
+func (pe ProtocolError) Error() string {
+	return fmt.Sprintf("%s (offset %d)", pe.e, pe.Offset)
+}
+
+func newProtocolError(b byte, offset int64, format string, values ...interface{}) ProtocolError {
+	return ProtocolError{b, offset, fmt.Errorf(format, values...)}
+}
+
 type RedisError struct {
 	Prefix string
 	Suffix string
@@ -206,108 +410,357 @@ func parseError(s string) RedisError {
 	return RedisError{p[0], p[1]}
 }
 
+// Encode encodes i, which must be a string, []byte, []string or [][]byte, and
+// panics otherwise. EncodeErr returns an error instead.
 func Encode(i interface{}) string {
+	s, err := EncodeErr(i)
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+// EncodeErr is Encode, returning a ProtocolError for unsupported types.
+func EncodeErr(i interface{}) (string, error) {
 	switch t := i.(type) {
 	case []string:
 		s := []string{"*", strconv.Itoa(len(t)), "\r\n"}
 		for _, v := range t {
-			s = append(s, Encode(v))
+			s = append(s, encodeBulk(v))
 		}
-		return strings.Join(s, "")
+		return strings.Join(s, ""), nil
+	case [][]byte:
+		s := []string{"*", strconv.Itoa(len(t)), "\r\n"}
+		for _, v := range t {
+			s = append(s, encodeBulk(string(v)))
+		}
+		return strings.Join(s, ""), nil
 	case string:
-		return "$" + strconv.Itoa(len(t)) + "\r\n" + t + "\r\n"
-	default:
-		panic(fmt.Sprintf("Unable to Encode type: %#v", t))
+		return encodeBulk(t), nil
+	case []byte:
+		return encodeBulk(string(t)), nil
 	}
+	return "", newProtocolError(0, 0, "Unable to Encode type: %T", i)
+}
+
+func encodeBulk(s string) string {
+	return "$" + strconv.Itoa(len(s)) + "\r\n" + s + "\r\n"
+}
+
+type decoder struct {
+	r      *bufio.Reader
+	bytes  bool
+	strict bool
+	opts   DecoderOptions
+	offset int64
+	depth  int
 }
 
 func Decode(r *bufio.Reader) (interface{}, error) {
-	t, err := r.ReadByte()
+	return (&decoder{r: r}).decode()
+}
+
+// DecodeReply is Decode returning a Reply. Error replies are returned as a
+// Reply of KindError rather than as a RedisError.
+func DecodeReply(r *bufio.Reader) (Reply, error) {
+	return (&decoder{r: r}).reply()
+}
+
+// DecodeStrict is Decode, except that a newline byte without a preceding
+// carriage return is rejected rather than read as part of a line.
+func DecodeStrict(r *bufio.Reader) (interface{}, error) {
+	return (&decoder{r: r, strict: true}).decode()
+}
+
+// DecodeBytes is Decode, except that Bulk and Verbatim String payloads are
+// returned as the []byte read off the wire instead of being copied to a string.
+func DecodeBytes(r *bufio.Reader) (interface{}, error) {
+	return (&decoder{r: r, bytes: true}).decode()
+}
+
+func (d *decoder) decode() (interface{}, error) {
+	r, err := d.reply()
 	if err != nil {
-		return nil, newReaderError("Failed to get Redis type byte in to call ReadByte: %s", err)
+		return nil, err
+	}
+	return r.value(d.bytes)
+}
+
+func (d *decoder) reply() (Reply, error) {
+	t, err := d.readByte()
+	if err != nil {
+		return Reply{}, readError("Failed to get Redis type byte in to call ReadByte: %w", err)
 	}
 	//fmt.Println("Type:", string(t))
 	switch string(t) {
 	case "-":
-		s, err := redisReadString(r)
+		s, err := d.readString()
 		if err != nil {
-			return nil, newReaderError("Failed to get Error string in call to ReadString: %s", err)
+			return Reply{}, readError("Failed to get Error string in call to ReadString: %w", err)
 		}
-		return nil, parseError(s)
+		return Reply{Kind: KindError, str: []byte(s)}, nil
 	case "+":
-		tmp, err := redisReadString(r)
-		return tmp, err
+		s, err := d.readString()
+		if err != nil {
+			return Reply{}, err
+		}
+		return Reply{Kind: KindSimpleString, str: []byte(s)}, nil
 	case ":":
-		return decodeIntSuffix(r)
+		return d.decodeIntSuffix()
 	case "$":
-		return decodeBulkStringSuffix(r)
+		return d.decodeBulkStringSuffix(KindBulk)
 	case "*":
-		return decodeArraySuffix(r)
+		return d.decodeArraySuffix(KindArray)
+	case "~":
+		return d.decodeArraySuffix(KindSet)
+	case "%":
+		return d.decodeMapSuffix()
+	case ",":
+		return d.decodeDoubleSuffix()
+	case "#":
+		return d.decodeBooleanSuffix()
+	case "(":
+		return d.decodeBigNumberSuffix()
+	case "_":
+		_, err := d.readString()
+		if err != nil {
+			return Reply{}, readError("Failed to get Null terminator in call to ReadString: %w", err)
+		}
+		return Reply{Kind: KindNull}, nil
+	case "=":
+		return d.decodeVerbatimSuffix()
+	case "!":
+		return d.decodeBulkStringSuffix(KindError)
+	case "|":
+		attrs, err := d.decodeMapSuffix()
+		if err != nil {
+			return Reply{}, err
+		}
+		r, err := d.reply()
+		if err != nil {
+			return Reply{}, err
+		}
+		r.attrs = attrs.elems
+		return r, nil
+	case ">":
+		return d.decodeArraySuffix(KindPush)
 	}
-	panic(fmt.Sprintf("Failed to identify type: '%q'", string(t)))
+	return Reply{}, newProtocolError(t, d.offset-1, "Failed to identify type: %q", t)
 }
 
-func decodeIntSuffix(r *bufio.Reader) (interface{}, error) {
-	s, err := redisReadString(r)
+func (d *decoder) decodeIntSuffix() (Reply, error) {
+	s, err := d.readString()
 	if err != nil {
-		return nil, newReaderError("Failed to get raw int in call to ReadString: %s", err)
+		return Reply{}, readError("Failed to get raw int in call to ReadString: %w", err)
 	}
 	i, err := toInt(s)
 	if err != nil {
-		return nil, newConversionError("Failed to convert raw int to int: %s", err)
+		return Reply{}, newConversionError("Failed to convert raw int to int: %s", err)
 	}
-	return i, nil
+	return Reply{Kind: KindInteger, int: i}, nil
 }
 
-func decodeBulkStringSuffix(r *bufio.Reader) (interface{}, error) {
-	tmp, err := redisReadString(r)
+func (d *decoder) decodeBulkStringSuffix(kind Kind) (Reply, error) {
+	s, null, err := d.readBulk()
 	if err != nil {
-		return nil, newReaderError("Failed to get raw int for Bulk String size in call to ReadString: %s", err)
+		return Reply{}, err
+	}
+	if null {
+		return Reply{Kind: KindNull}, nil
+	}
+	return Reply{Kind: kind, str: s}, nil
+}
+
+func (d *decoder) readBulk() ([]byte, bool, error) {
+	start := d.offset
+	tmp, err := d.readString()
+	if err != nil {
+		return nil, false, readError("Failed to get raw int for Bulk String size in call to ReadString: %w", err)
 	}
 	if isNegativeOne(tmp) {
 		//fmt.Println("Negative one - redis null on bulk empty string")
-		return nil, nil
+		return nil, true, nil
 	}
 	slen, err := toUint(tmp)
 	if err != nil {
-		return nil, newConversionError("Failed to convert raw int to int for Bulk String size: %s", err)
+		return nil, false, newConversionError("Failed to convert raw int to int for Bulk String size: %s", err)
+	}
+	if err := sizeError(slen, maxInt, start, tmp); err != nil {
+		return nil, false, err
 	}
-	s := make([]byte, slen)
-	_, err = io.ReadFull(r, s)
-	if err == io.EOF {
-		return nil, fmt.Errorf("Unable to read any bytes")
+	if err := d.checkBulk(slen); err != nil {
+		return nil, false, err
+	}
+	s, err := d.readPayload(int(slen))
+	if err == io.EOF && len(s) == 0 {
+		return nil, false, newReaderError("Unable to read any bytes")
 	}
 	if err != nil {
-		return nil, fmt.Errorf("Unable to read required number of bytes: %s", err)
+		return nil, false, newReaderError("Unable to read required number of bytes: %w", err)
+	}
+	if err := d.readTerminator(); err != nil {
+		return nil, false, err
+	}
+	return s, false, nil
+}
+
+// bulkChunk is the most memory a Bulk String is given before its payload has
+// actually arrived, so a false size in its header can not force a huge
+// allocation.
+const bulkChunk = 64 << 10
+
+const maxInt = int(^uint(0) >> 1)
+
+// readPayload reads n bytes, growing the buffer as they arrive rather than
+// allocating all of it up front.
+func (d *decoder) readPayload(n int) ([]byte, error) {
+	size := n
+	if size > bulkChunk {
+		size = bulkChunk
+	}
+	s := make([]byte, 0, size)
+	for len(s) < n {
+		if len(s) == cap(s) {
+			size = n - len(s)
+			if size > len(s) {
+				size = len(s)
+			}
+			t := make([]byte, len(s), len(s)+size)
+			copy(t, s)
+			s = t
+		}
+		m, err := io.ReadFull(d.r, s[len(s):cap(s)])
+		s = s[:len(s)+m]
+		d.offset += int64(m)
+		if err != nil {
+			return s, err
+		}
+	}
+	return s, nil
+}
+
+// sizeError rejects the size s, read at offset start, if n is more than max.
+func sizeError(n uint64, max int, start int64, s string) error {
+	if n > uint64(max) {
+		return newProtocolError(s[0], start, "Size out of range: %s", s)
 	}
-	r.ReadByte()
-	r.ReadByte()
-	return string(s), nil
+	return nil
 }
 
-func decodeArraySuffix(r *bufio.Reader) (interface{}, error) {
-	tmp, err := redisReadString(r)
+func (d *decoder) decodeArraySuffix(kind Kind) (Reply, error) {
+	start := d.offset
+	tmp, err := d.readString()
 	if err != nil {
-		return nil, newReaderError("Failed to get raw int for Bulk Array size in call to ReadString: %s", err)
+		return Reply{}, readError("Failed to get raw int for Bulk Array size in call to ReadString: %w", err)
 	}
 	if isNegativeOne(tmp) {
-		return nil, nil
+		return Reply{Kind: KindNull}, nil
 	}
 	alen, err := toUint(tmp)
 	if err != nil {
-		return nil, newConversionError("Failed to convert raw int to int for Bulk Array size: %s", err)
+		return Reply{}, newConversionError("Failed to convert raw int to int for Bulk Array size: %s", err)
+	}
+	if err := sizeError(alen, maxInt, start, tmp); err != nil {
+		return Reply{}, err
 	}
-	result := make([]interface{}, 0, alen)
-	for i := uint64(0); i < alen; i++ {
-		v, err := Decode(r)
+	return d.decodeElems(kind, alen, alen)
+}
+
+func (d *decoder) decodeMapSuffix() (Reply, error) {
+	start := d.offset
+	tmp, err := d.readString()
+	if err != nil {
+		return Reply{}, readError("Failed to get raw int for Map size in call to ReadString: %w", err)
+	}
+	mlen, err := toUint(tmp)
+	if err != nil {
+		return Reply{}, newConversionError("Failed to convert raw int to int for Map size: %s", err)
+	}
+	if err := sizeError(mlen, maxInt/2, start, tmp); err != nil {
+		return Reply{}, err
+	}
+	return d.decodeElems(KindMap, mlen, 2*mlen)
+}
+
+// maxElemsPrealloc bounds the room made for the elements of an aggregate
+// before they have arrived.
+const maxElemsPrealloc = 1024
+
+// decodeElems reads the n elements of an aggregate reply, which has size
+// elements as far as MaxArrayLen is concerned.
+func (d *decoder) decodeElems(kind Kind, size, n uint64) (Reply, error) {
+	if err := d.checkAggregate(size); err != nil {
+		return Reply{}, err
+	}
+	d.depth++
+	defer func() { d.depth-- }()
+	prealloc := n
+	if prealloc > maxElemsPrealloc {
+		prealloc = maxElemsPrealloc
+	}
+	result := Reply{Kind: kind, elems: make([]Reply, 0, prealloc)}
+	for i := uint64(0); i < n; i++ {
+		v, err := d.reply()
 		if err != nil {
-			return nil, err
+			return Reply{}, err
 		}
-		result = append(result, v)
+		result.elems = append(result.elems, v)
 	}
 	return result, nil
 }
 
+func (d *decoder) decodeDoubleSuffix() (Reply, error) {
+	s, err := d.readString()
+	if err != nil {
+		return Reply{}, readError("Failed to get raw double in call to ReadString: %w", err)
+	}
+	f, err := toFloat(s)
+	if err != nil {
+		return Reply{}, newConversionError("Failed to convert raw double to float64: %s", err)
+	}
+	return Reply{Kind: KindDouble, float: f}, nil
+}
+
+func (d *decoder) decodeBooleanSuffix() (Reply, error) {
+	s, err := d.readString()
+	if err != nil {
+		return Reply{}, readError("Failed to get raw boolean in call to ReadString: %w", err)
+	}
+	switch s {
+	case "t":
+		return Reply{Kind: KindBoolean, int: 1}, nil
+	case "f":
+		return Reply{Kind: KindBoolean}, nil
+	}
+	return Reply{}, newConversionError("Failed to convert raw boolean to bool: %q", s)
+}
+
+func (d *decoder) decodeBigNumberSuffix() (Reply, error) {
+	s, err := d.readString()
+	if err != nil {
+		return Reply{}, readError("Failed to get raw big number in call to ReadString: %w", err)
+	}
+	b, ok := new(big.Int).SetString(s, 10)
+	if !ok {
+		return Reply{}, newConversionError("Failed to convert raw big number to *big.Int: %q", s)
+	}
+	return Reply{Kind: KindBigNumber, big: b}, nil
+}
+
+func (d *decoder) decodeVerbatimSuffix() (Reply, error) {
+	s, null, err := d.readBulk()
+	if err != nil {
+		return Reply{}, err
+	}
+	if null {
+		return Reply{Kind: KindNull}, nil
+	}
+	if len(s) < 4 || s[3] != ':' {
+		return Reply{}, newConversionError("Failed to find format prefix of Verbatim String: %q", s)
+	}
+	return Reply{Kind: KindVerbatim, str: s[4:], format: string(s[:3])}, nil
+}
+
 func isNegativeOne(s string) bool {
 	return len(s) == 2 && s[0] == '-' && s[1] == '1'
 }
@@ -320,24 +773,73 @@ func toInt(s string) (int64, error) {
 	return strconv.ParseInt(strings.TrimSpace(s), 10, 64)
 }
 
-func redisReadString(r *bufio.Reader) (string, error) {
+func toFloat(s string) (float64, error) {
+	return strconv.ParseFloat(strings.TrimSpace(s), 64)
+}
+
+func (d *decoder) readByte() (byte, error) {
+	if err := d.checkTotal(1); err != nil {
+		return 0, err
+	}
+	b, err := d.r.ReadByte()
+	if err == nil {
+		d.offset++
+	}
+	return b, err
+}
+
+// readTerminator consumes the CRLF following a Bulk String payload. Anything
+// else means the size in the header was wrong, and the stream is out of step.
+func (d *decoder) readTerminator() error {
+	for _, want := range []byte("\r\n") {
+		b, err := d.readByte()
+		if err != nil {
+			return readError("Failed to read Bulk String terminator: %w", err)
+		}
+		if b != want {
+			return newProtocolError(b, d.offset-1, "Invalid Bulk String terminator byte: %q", b)
+		}
+	}
+	return nil
+}
+
+func (d *decoder) readString() (string, error) {
 	var out bytes.Buffer
 	for {
-		b, err := r.ReadByte()
+		b, err := d.readByte()
 		if err != nil {
-			return "", fmt.Errorf("failed to read byte: %s", err)
+			return "", byteError(err)
 		}
 		if b == '\r' {
-			b, err := r.ReadByte()
+			b, err := d.readByte()
 			if err != nil {
-				return "", fmt.Errorf("failed to read byte: %s", err)
+				return "", byteError(err)
 			}
 			if b != '\n' {
-				return "", fmt.Errorf("failed to read required final newline byte")
+				return "", newProtocolError(b, d.offset-1, "Failed to read required final newline byte, got: %q", b)
 			}
-			//return strings.TrimSuffix(out.String(), "\r\n"), nil
 			return out.String(), nil
 		}
+		if d.strict && b == '\n' {
+			return "", newProtocolError(b, d.offset-1, "Found newline byte without a preceding carriage return")
+		}
 		out.WriteByte(b)
 	}
 }
+
+func byteError(err error) error {
+	if le, ok := err.(LimitError); ok {
+		return le
+	}
+	return fmt.Errorf("failed to read byte: %w", err)
+}
+
+// readError keeps ProtocolErrors and LimitErrors as they are and wraps
+// anything else in a ReaderError.
+func readError(format string, err error) error {
+	switch err.(type) {
+	case ProtocolError, LimitError:
+		return err
+	}
+	return newReaderError(format, err)
+}
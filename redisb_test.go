@@ -3,6 +3,9 @@ package redisb
 import (
 	"bufio"
 	"errors"
+	"math"
+	"math/big"
+	"reflect"
 	"strings"
 	"testing"
 )
@@ -42,11 +45,11 @@ func TestDecode(t *testing.T) {
 		{"$1\r\na\r\n", "a", nil},
 		{"$1\r\n", nil, errors.New("")},
 		{"$2\r\na\r\n", nil, errors.New("")},
-		{"$-1\r\n", RedisNil{}, nil},
+		{"$-1\r\n", nil, nil},
 		// Arrays
 		{"*", nil, newReaderError("")},
 		{"*0\r\n", []string{}, nil},
-		{"*-1\r\n", RedisNil{}, nil},
+		{"*-1\r\n", nil, nil},
 		{"*1\r\n:1\r\n", []int{1}, nil},
 		{"*1\r\n", nil, errors.New("")},
 		{"*2\r\n:1\r\n", nil, errors.New("")},
@@ -60,6 +63,102 @@ func TestDecode(t *testing.T) {
 	}
 }
 
+func TestDecodeRESP3(t *testing.T) {
+	bs := func(s string) *bufio.Reader { return bufio.NewReader(strings.NewReader(s)) }
+	cases := []struct {
+		in  string
+		out interface{}
+	}{
+		{"%2\r\n+a\r\n:1\r\n$1\r\nb\r\n*1\r\n:2\r\n", map[string]interface{}{"a": int64(1), "b": []interface{}{int64(2)}}},
+		{"%1\r\n:7\r\n#t\r\n", map[string]interface{}{"7": true}},
+		{"~2\r\n+a\r\n+b\r\n", []interface{}{"a", "b"}},
+		{",1.5\r\n", 1.5},
+		{",-inf\r\n", math.Inf(-1)},
+		{"#t\r\n", true},
+		{"#f\r\n", false},
+		{"(3492890328409238509324850943850943825024385\r\n", func() *big.Int {
+			b, _ := new(big.Int).SetString("3492890328409238509324850943850943825024385", 10)
+			return b
+		}()},
+		{"_\r\n", nil},
+		{"=15\r\ntxt:Some string\r\n", "Some string"},
+		{"|1\r\n+key-popularity\r\n%1\r\n$1\r\na\r\n,0.19\r\n:2\r\n", int64(2)},
+		{">2\r\n+message\r\n+hi\r\n", Push{"message", "hi"}},
+	}
+	for _, c := range cases {
+		got, err := Decode(bs(c.in))
+		if err != nil {
+			t.Errorf("Decode: %q: %s", c.in, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, c.out) {
+			t.Errorf("Decode: %q: %#v - %#v", c.in, c.out, got)
+		}
+	}
+	_, err := Decode(bs("!21\r\nSYNTAX invalid syntax\r\n"))
+	if err != (RedisError{"SYNTAX", "invalid syntax"}) {
+		t.Errorf("Decode blob error: %#v", err)
+	}
+	if _, err := Decode(bs("#x\r\n")); err == nil {
+		t.Error("Decode: expected error for invalid boolean")
+	}
+}
+
+func TestRESP3Converters(t *testing.T) {
+	if f, err := toFloat64("2.5"); err != nil || f != 2.5 {
+		t.Errorf("toFloat64: %v, %v", f, err)
+	}
+	if b, err := toBigInt(int64(9)); err != nil || b.Int64() != 9 {
+		t.Errorf("toBigInt: %v, %v", b, err)
+	}
+	m, err := toMap([]interface{}{"a", int64(1)})
+	if err != nil || !reflect.DeepEqual(m, map[string]interface{}{"a": int64(1)}) {
+		t.Errorf("toMap: %v, %v", m, err)
+	}
+	if _, err := toMap([]interface{}{"a"}); err == nil {
+		t.Error("toMap: expected error for odd length")
+	}
+	if b, err := toBool(true); err != nil || !b {
+		t.Errorf("toBool: %v, %v", b, err)
+	}
+}
+
+func TestProtocolError(t *testing.T) {
+	bs := func(s string) *bufio.Reader { return bufio.NewReader(strings.NewReader(s)) }
+	cases := []struct {
+		in     string
+		strict bool
+		b      byte
+		offset int64
+	}{
+		{"?", false, '?', 0},
+		{"*2\r\n:1\r\n@", false, '@', 8},
+		{"+a\rb", false, 'b', 3},
+		{"$1\r\naXY", false, 'X', 5},
+		{"$1\r\naXY", true, 'X', 5},
+		{"+a\nb\r\n", true, '\n', 2},
+		{"$18446744073709551615\r\n", false, '1', 1},
+		{"*18446744073709551615\r\n", false, '1', 1},
+		{"%9223372036854775807\r\n", false, '9', 1},
+	}
+	for _, c := range cases {
+		d := &decoder{r: bs(c.in), strict: c.strict}
+		_, err := d.decode()
+		pe, ok := err.(ProtocolError)
+		if !ok || pe.Byte != c.b || pe.Offset != c.offset {
+			t.Errorf("decode: %q: expected ProtocolError at %d, got %#v", c.in, c.offset, err)
+		}
+	}
+	if _, err := Decode(bs("*9999999999999\r\n")); err == nil {
+		t.Error("Decode: expected error for truncated Array")
+	}
+	if _, err := EncodeErr(42); err == nil {
+		t.Error("EncodeErr: expected ProtocolError")
+	} else if _, ok := err.(ProtocolError); !ok {
+		t.Errorf("EncodeErr: expected ProtocolError, got %#v", err)
+	}
+}
+
 func TestToInt(t *testing.T) {
 	cases := []struct {
 		in  string
@@ -115,3 +214,27 @@ func TestParseError(t *testing.T) {
 		}
 	}
 }
+
+func TestNestedErrors(t *testing.T) {
+	r := bufio.NewReader(strings.NewReader("*3\r\n:1\r\n-WRONGTYPE wrong kind\r\n$1\r\nv\r\n:7\r\n"))
+	got, err := Decode(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wrong := RedisError{"WRONGTYPE", "wrong kind"}
+	if !reflect.DeepEqual(got, []interface{}{int64(1), wrong, "v"}) {
+		t.Errorf("Decode: %#v", got)
+	}
+	if errs := ElemErrors(got.([]interface{})); !reflect.DeepEqual(errs, []error{nil, wrong, nil}) {
+		t.Errorf("ElemErrors: %#v", errs)
+	}
+	if errs := ElemErrors([]interface{}{"a", int64(1)}); errs != nil {
+		t.Errorf("ElemErrors without errors: %#v", errs)
+	}
+	if _, err := toStrings(got); err != wrong {
+		t.Errorf("toStrings: %#v", err)
+	}
+	if next, err := Decode(r); err != nil || next != int64(7) {
+		t.Errorf("Decode after nested error: %#v, %v", next, err)
+	}
+}
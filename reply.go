@@ -0,0 +1,208 @@
+package redisb
+
+import (
+	"math/big"
+)
+
+// Kind is the RESP type of a Reply.
+type Kind int
+
+const (
+	KindSimpleString Kind = iota
+	KindError
+	KindInteger
+	KindBulk
+	KindArray
+	KindNull
+	KindMap
+	KindSet
+	KindDouble
+	KindBoolean
+	KindBigNumber
+	KindVerbatim
+	KindPush
+)
+
+var kindNames = [...]string{
+	KindSimpleString: "SimpleString",
+	KindError:        "Error",
+	KindInteger:      "Integer",
+	KindBulk:         "Bulk",
+	KindArray:        "Array",
+	KindNull:         "Null",
+	KindMap:          "Map",
+	KindSet:          "Set",
+	KindDouble:       "Double",
+	KindBoolean:      "Boolean",
+	KindBigNumber:    "BigNumber",
+	KindVerbatim:     "Verbatim",
+	KindPush:         "Push",
+}
+
+func (k Kind) String() string {
+	if k < 0 || int(k) >= len(kindNames) {
+		return "Kind(?)"
+	}
+	return kindNames[k]
+}
+
+// Reply is a decoded reply that keeps its RESP type. The accessors convert
+// it the same way the typed helpers convert the result of Decode.
+type Reply struct {
+	Kind Kind
+
+	str    []byte  // SimpleString, Error, Bulk and Verbatim payloads
+	format string  // Verbatim format, such as "txt"
+	int    int64   // Integer, and Boolean as 0 or 1
+	float  float64 // Double
+	big    *big.Int
+	elems  []Reply // Array, Set and Push elements, or Map keys and values in turn
+	attrs  []Reply // RESP3 attribute keys and values in turn
+}
+
+func (r Reply) IsNil() bool {
+	return r.Kind == KindNull
+}
+
+// Err returns the RedisError carried by a KindError Reply, and nil otherwise.
+func (r Reply) Err() error {
+	if r.Kind != KindError {
+		return nil
+	}
+	return parseError(string(r.str))
+}
+
+// Elems returns the elements of an Array, Set or Push, or the keys and values
+// of a Map in turn.
+func (r Reply) Elems() []Reply {
+	return r.elems
+}
+
+// Attrs returns the keys and values, in turn, of any RESP3 attributes sent
+// ahead of the reply.
+func (r Reply) Attrs() []Reply {
+	return r.attrs
+}
+
+// Format returns the format of a Verbatim String, such as "txt" or "mkd".
+func (r Reply) Format() string {
+	return r.format
+}
+
+// Value returns the reply as Decode would have.
+func (r Reply) Value() (interface{}, error) {
+	return r.value(false)
+}
+
+func (r Reply) Int64() (int64, error) {
+	i, err := r.value(false)
+	if err != nil {
+		return 0, err
+	}
+	return toInt64(i)
+}
+
+func (r Reply) Float64() (float64, error) {
+	i, err := r.value(false)
+	if err != nil {
+		return 0, err
+	}
+	return toFloat64(i)
+}
+
+func (r Reply) Bool() (bool, error) {
+	i, err := r.value(false)
+	if err != nil {
+		return false, err
+	}
+	return toBool(i)
+}
+
+func (r Reply) BigInt() (*big.Int, error) {
+	i, err := r.value(false)
+	if err != nil {
+		return nil, err
+	}
+	return toBigInt(i)
+}
+
+func (r Reply) Str() (string, error) {
+	i, err := r.value(false)
+	if err != nil {
+		return "", err
+	}
+	return toString(i)
+}
+
+func (r Reply) Bytes() ([]byte, error) {
+	i, err := r.value(true)
+	if err != nil {
+		return nil, err
+	}
+	return toBytes(i)
+}
+
+func (r Reply) value(bytes bool) (interface{}, error) {
+	switch r.Kind {
+	case KindError:
+		return nil, r.Err()
+	case KindNull:
+		return nil, nil
+	case KindSimpleString:
+		return string(r.str), nil
+	case KindBulk, KindVerbatim:
+		if bytes {
+			return r.str, nil
+		}
+		return string(r.str), nil
+	case KindInteger:
+		return r.int, nil
+	case KindDouble:
+		return r.float, nil
+	case KindBoolean:
+		return r.int == 1, nil
+	case KindBigNumber:
+		return r.big, nil
+	case KindArray, KindSet, KindPush:
+		result := make([]interface{}, 0, len(r.elems))
+		for _, e := range r.elems {
+			v, err := e.elemValue(bytes)
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, v)
+		}
+		if r.Kind == KindPush {
+			return Push(result), nil
+		}
+		return result, nil
+	case KindMap:
+		result := make(map[string]interface{}, len(r.elems)/2)
+		for i := 0; i+1 < len(r.elems); i += 2 {
+			k, err := r.elems[i].value(bytes)
+			if err != nil {
+				return nil, err
+			}
+			v, err := r.elems[i+1].elemValue(bytes)
+			if err != nil {
+				return nil, err
+			}
+			sk, err := toString(k)
+			if err != nil {
+				return nil, newConversionError("Failed to convert Map key to string: %s", err)
+			}
+			result[sk] = v
+		}
+		return result, nil
+	}
+	return nil, newConversionError("Conversion of %s reply failed", r.Kind)
+}
+
+// elemValue is value for an element of an aggregate, where an error reply is
+// a RedisError value rather than an error.
+func (r Reply) elemValue(bytes bool) (interface{}, error) {
+	if r.Kind == KindError {
+		return r.Err(), nil
+	}
+	return r.value(bytes)
+}
@@ -0,0 +1,61 @@
+package redisb
+
+import (
+	"bufio"
+	"strings"
+	"testing"
+)
+
+func TestDecodeReply(t *testing.T) {
+	bs := func(s string) *bufio.Reader { return bufio.NewReader(strings.NewReader(s)) }
+	r, err := DecodeReply(bs("*5\r\n:1\r\n$-1\r\n*0\r\n-ERR bad\r\n=7\r\nmkd:# a\r\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Kind != KindArray || len(r.Elems()) != 5 {
+		t.Fatalf("DecodeReply: %s with %d elements", r.Kind, len(r.Elems()))
+	}
+	e := r.Elems()
+	if i, err := e[0].Int64(); e[0].Kind != KindInteger || err != nil || i != 1 {
+		t.Errorf("Integer element: %s %d %v", e[0].Kind, i, err)
+	}
+	if !e[1].IsNil() {
+		t.Errorf("Null element: %s", e[1].Kind)
+	}
+	if e[2].Kind != KindArray || e[2].IsNil() || len(e[2].Elems()) != 0 {
+		t.Errorf("Empty Array element: %s", e[2].Kind)
+	}
+	if e[3].Kind != KindError || e[3].Err() != (RedisError{"ERR", "bad"}) {
+		t.Errorf("Error element: %s %v", e[3].Kind, e[3].Err())
+	}
+	if _, err := e[3].Str(); err == nil {
+		t.Error("Str on Error element: expected RedisError")
+	}
+	if s, err := e[4].Str(); e[4].Kind != KindVerbatim || e[4].Format() != "mkd" || s != "# a" {
+		t.Errorf("Verbatim element: %s %q %q %v", e[4].Kind, e[4].Format(), s, err)
+	}
+	if b, err := e[4].Bytes(); err != nil || string(b) != "# a" {
+		t.Errorf("Bytes: %q %v", b, err)
+	}
+
+	r, err = DecodeReply(bs("|1\r\n+ttl\r\n:3\r\n#t\r\n"))
+	if err != nil || r.Kind != KindBoolean || len(r.Attrs()) != 2 {
+		t.Fatalf("DecodeReply attribute: %s %d %v", r.Kind, len(r.Attrs()), err)
+	}
+	if b, err := r.Bool(); err != nil || !b {
+		t.Errorf("Bool: %v %v", b, err)
+	}
+}
+
+func TestConnReply(t *testing.T) {
+	c := fakeServer(t, func(cmd []string) string {
+		return "-WRONGTYPE Operation against a key holding the wrong kind of value\r\n"
+	})
+	r, err := c.Reply("lpush", "k", "v")
+	if err != nil || r.Kind != KindError {
+		t.Fatalf("Reply: %s %v", r.Kind, err)
+	}
+	if re, ok := r.Err().(RedisError); !ok || re.Prefix != "WRONGTYPE" {
+		t.Errorf("Err: %#v", r.Err())
+	}
+}
@@ -0,0 +1,281 @@
+package redisb
+
+import (
+	"errors"
+	"math/big"
+)
+
+// ErrNotExecuted is returned by the Result of a command that has not been
+// sent yet.
+var ErrNotExecuted = errors.New("Result read before the command was executed")
+
+// result is the reply to one command queued on a Pipeline or Tx, filled in
+// once the command has run.
+type result struct {
+	args  interface{}
+	bytes bool
+	done  bool
+	reply Reply
+	err   error
+}
+
+func (r *result) set(reply Reply, err error) {
+	r.reply, r.err, r.done = reply, err, true
+}
+
+func (r *result) value() (interface{}, error) {
+	if !r.done {
+		return nil, ErrNotExecuted
+	}
+	if r.err != nil {
+		return nil, r.err
+	}
+	return r.reply.value(r.bytes)
+}
+
+// Reply returns the reply with the RESP type intact.
+func (r *result) Reply() (Reply, error) {
+	if !r.done {
+		return Reply{}, ErrNotExecuted
+	}
+	return r.reply, r.err
+}
+
+// Err returns the error the command failed with, including a RedisError
+// reply, or nil.
+func (r *result) Err() error {
+	_, err := r.value()
+	return err
+}
+
+type RawResult struct{ result }
+
+func (r *RawResult) Result() (interface{}, error) { return r.value() }
+
+type IntResult struct{ result }
+
+func (r *IntResult) Result() (int64, error) {
+	i, err := r.value()
+	if err != nil {
+		return 0, err
+	}
+	return toInt64(i)
+}
+
+type BoolResult struct{ result }
+
+func (r *BoolResult) Result() (bool, error) {
+	i, err := r.value()
+	if err != nil {
+		return false, err
+	}
+	return toBool(i)
+}
+
+type StringResult struct{ result }
+
+func (r *StringResult) Result() (string, error) {
+	i, err := r.value()
+	if err != nil {
+		return "", err
+	}
+	return toString(i)
+}
+
+type FloatResult struct{ result }
+
+func (r *FloatResult) Result() (float64, error) {
+	i, err := r.value()
+	if err != nil {
+		return 0, err
+	}
+	return toFloat64(i)
+}
+
+type BigIntResult struct{ result }
+
+func (r *BigIntResult) Result() (*big.Int, error) {
+	i, err := r.value()
+	if err != nil {
+		return nil, err
+	}
+	return toBigInt(i)
+}
+
+type MapResult struct{ result }
+
+func (r *MapResult) Result() (map[string]interface{}, error) {
+	i, err := r.value()
+	if err != nil {
+		return nil, err
+	}
+	return toMap(i)
+}
+
+type ArrayResult struct{ result }
+
+func (r *ArrayResult) Result() ([]interface{}, error) {
+	i, err := r.value()
+	if err != nil {
+		return nil, err
+	}
+	return toArray(i)
+}
+
+type BoolsResult struct{ result }
+
+func (r *BoolsResult) Result() ([]bool, error) {
+	i, err := r.value()
+	if err != nil {
+		return nil, err
+	}
+	return toBools(i)
+}
+
+type Int64sResult struct{ result }
+
+func (r *Int64sResult) Result() ([]int64, error) {
+	i, err := r.value()
+	if err != nil {
+		return nil, err
+	}
+	return toInt64s(i)
+}
+
+type StringsResult struct{ result }
+
+func (r *StringsResult) Result() ([]string, error) {
+	i, err := r.value()
+	if err != nil {
+		return nil, err
+	}
+	return toStrings(i)
+}
+
+type BytesResult struct{ result }
+
+func (r *BytesResult) Result() ([]byte, error) {
+	i, err := r.value()
+	if err != nil {
+		return nil, err
+	}
+	return toBytes(i)
+}
+
+type BytesSliceResult struct{ result }
+
+func (r *BytesSliceResult) Result() ([][]byte, error) {
+	i, err := r.value()
+	if err != nil {
+		return nil, err
+	}
+	return toBytesSlice(i)
+}
+
+// queue collects commands along with the results they fill in. Its methods
+// are those of Pipeline and Tx.
+type queue struct {
+	results []*result
+}
+
+func (q *queue) add(r *result, args interface{}, bytes bool) {
+	r.args, r.bytes = args, bytes
+	q.results = append(q.results, r)
+}
+
+// Do queues a command whose arguments may be of any type Args accepts.
+func (q *queue) Do(args ...interface{}) *RawResult {
+	r := &RawResult{}
+	q.add(&r.result, Args(args), false)
+	return r
+}
+
+func (q *queue) Raw(args ...string) *RawResult {
+	r := &RawResult{}
+	q.add(&r.result, args, false)
+	return r
+}
+
+func (q *queue) Int64(args ...string) *IntResult {
+	r := &IntResult{}
+	q.add(&r.result, args, false)
+	return r
+}
+
+func (q *queue) Bool(args ...string) *BoolResult {
+	r := &BoolResult{}
+	q.add(&r.result, args, false)
+	return r
+}
+
+func (q *queue) String(args ...string) *StringResult {
+	r := &StringResult{}
+	q.add(&r.result, args, false)
+	return r
+}
+
+func (q *queue) Float64(args ...string) *FloatResult {
+	r := &FloatResult{}
+	q.add(&r.result, args, false)
+	return r
+}
+
+func (q *queue) BigInt(args ...string) *BigIntResult {
+	r := &BigIntResult{}
+	q.add(&r.result, args, false)
+	return r
+}
+
+func (q *queue) Map(args ...string) *MapResult {
+	r := &MapResult{}
+	q.add(&r.result, args, false)
+	return r
+}
+
+func (q *queue) Array(args ...string) *ArrayResult {
+	r := &ArrayResult{}
+	q.add(&r.result, args, false)
+	return r
+}
+
+func (q *queue) Bools(args ...string) *BoolsResult {
+	r := &BoolsResult{}
+	q.add(&r.result, args, false)
+	return r
+}
+
+func (q *queue) Int64s(args ...string) *Int64sResult {
+	r := &Int64sResult{}
+	q.add(&r.result, args, false)
+	return r
+}
+
+func (q *queue) Strings(args ...string) *StringsResult {
+	r := &StringsResult{}
+	q.add(&r.result, args, false)
+	return r
+}
+
+func (q *queue) Bytes(args ...string) *BytesResult {
+	r := &BytesResult{}
+	q.add(&r.result, args, true)
+	return r
+}
+
+func (q *queue) BytesSlice(args ...string) *BytesSliceResult {
+	r := &BytesSliceResult{}
+	q.add(&r.result, args, true)
+	return r
+}
+
+// Len returns the number of commands queued.
+func (q *queue) Len() int {
+	return len(q.results)
+}
+
+// take empties q and returns what was queued.
+func (q *queue) take() []*result {
+	results := q.results
+	q.results = nil
+	return results
+}
@@ -0,0 +1,131 @@
+package redisb
+
+import (
+	"math/rand"
+	"strings"
+	"time"
+)
+
+// RetryPolicy makes a Conn from Dial reconnect when it has been left
+// unusable, and retry commands that failed because the connection broke.
+//
+// A Conn that is already unusable reconnects before sending any command.
+// Once a command may have reached the server, it is only retried if it is
+// read-only or otherwise safe to repeat, see RetryableCommands. Commands like
+// INCR or LPUSH are never sent twice. In a call with a context, reconnecting
+// stops with ctx.Err() once the context is done.
+type RetryPolicy struct {
+	// MaxAttempts is the most times a command is tried, including the first.
+	MaxAttempts int
+	// MinBackoff is the wait before the first retry. Each later retry waits
+	// twice as long as the one before, up to MaxBackoff, less a random jitter
+	// of up to half.
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+}
+
+// RetryableCommands are the commands a RetryPolicy repeats after a failure.
+var RetryableCommands = map[string]bool{
+	"bitcount": true, "bitpos": true, "dbsize": true, "dump": true,
+	"echo": true, "exists": true, "geodist": true, "geohash": true,
+	"geopos": true, "get": true, "getbit": true, "getrange": true,
+	"hexists": true, "hget": true, "hgetall": true, "hkeys": true,
+	"hlen": true, "hmget": true, "hscan": true, "hstrlen": true,
+	"hvals": true, "keys": true, "lindex": true, "llen": true,
+	"lpos": true, "lrange": true, "mget": true, "object": true,
+	"pfcount": true, "ping": true, "pttl": true, "randomkey": true,
+	"scan": true, "scard": true, "sdiff": true, "sinter": true,
+	"sismember": true, "smembers": true, "smismember": true, "sscan": true,
+	"strlen": true, "sunion": true, "time": true, "ttl": true,
+	"type": true, "xlen": true, "xrange": true, "xrevrange": true,
+	"zcard": true, "zcount": true, "zlexcount": true, "zmscore": true,
+	"zrange": true, "zrangebylex": true, "zrangebyscore": true, "zrank": true,
+	"zrevrange": true, "zrevrangebylex": true, "zrevrangebyscore": true, "zrevrank": true,
+	"zscan": true, "zscore": true,
+}
+
+func (rp *RetryPolicy) do(c *Conn, args interface{}, bytes bool) (interface{}, error) {
+	retryable := RetryableCommands[commandName(args)]
+	for attempt := 1; ; attempt++ {
+		var i interface{}
+		var err error
+		sent := false
+		if c.err != nil {
+			ctx := c.callContext()
+			if ctx.Err() == nil {
+				err = c.reconnect(ctx)
+			}
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+		}
+		if err == nil {
+			sent = true
+			i, err = c.doOnce(args, bytes)
+		}
+		if err == nil || c.err == nil || attempt >= rp.MaxAttempts || (sent && !retryable) {
+			return i, err
+		}
+		if _, ok := c.err.(LimitError); ok {
+			return i, err
+		}
+		if !rp.sleep(c, attempt) {
+			return i, err
+		}
+	}
+}
+
+// sleep waits out the backoff before the given attempt is retried, and
+// reports false if the context of the current call ended first.
+func (rp *RetryPolicy) sleep(c *Conn, attempt int) bool {
+	done := c.callContext().Done()
+	d := rp.MinBackoff
+	for i := 1; i < attempt && (rp.MaxBackoff == 0 || d < rp.MaxBackoff); i++ {
+		d *= 2
+	}
+	if rp.MaxBackoff > 0 && d > rp.MaxBackoff {
+		d = rp.MaxBackoff
+	}
+	if d > 0 {
+		d -= time.Duration(rand.Int63n(int64(d)/2 + 1))
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return true
+	case <-done:
+		return false
+	}
+}
+
+func commandName(args interface{}) string {
+	return strings.ToLower(argAt(args, 0))
+}
+
+// argAt returns the i'th argument in args if it is a string or []byte, and
+// "" otherwise.
+func argAt(args interface{}, i int) string {
+	switch t := args.(type) {
+	case []string:
+		if i < len(t) {
+			return t[i]
+		}
+	case [][]byte:
+		if i < len(t) {
+			return string(t[i])
+		}
+	case Args:
+		return argAt([]interface{}(t), i)
+	case []interface{}:
+		if i < len(t) {
+			switch a := t[i].(type) {
+			case string:
+				return a
+			case []byte:
+				return string(a)
+			}
+		}
+	}
+	return ""
+}
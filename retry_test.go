@@ -0,0 +1,112 @@
+package redisb
+
+import (
+	"context"
+	"net"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRetry(t *testing.T) {
+	var mu sync.Mutex
+	seen := map[string]int{}
+	addr := listenServer(t, func(cmd []string) string {
+		mu.Lock()
+		defer mu.Unlock()
+		seen[cmd[0]]++
+		switch {
+		case cmd[0] == "hello":
+			return "%1\r\n+proto\r\n:3\r\n"
+		case cmd[0] == "get" && seen["get"] == 1:
+			return hangUp
+		case cmd[0] == "get":
+			return "$1\r\nv\r\n"
+		case cmd[0] == "incr":
+			return hangUp
+		}
+		return "+PONG\r\n"
+	})
+	policy := RetryPolicy{MaxAttempts: 3, MinBackoff: time.Millisecond, MaxBackoff: 4 * time.Millisecond}
+	c, err := Dial("tcp", addr, DialHello(3, HelloOptions{}), DialRetry(policy))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	if s, err := c.String("get", "k"); err != nil || s != "v" {
+		t.Fatalf("String: %q, %v", s, err)
+	}
+	if _, err := c.Int64("incr", "n"); err == nil {
+		t.Fatal("Int64: expected error from dropped connection")
+	}
+	if err := c.Ping(); err != nil {
+		t.Fatalf("Ping after dropped connection: %v", err)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if seen["incr"] != 1 {
+		t.Errorf("INCR sent %d times", seen["incr"])
+	}
+	if seen["hello"] != 3 {
+		t.Errorf("HELLO sent %d times, expected once per connection", seen["hello"])
+	}
+}
+
+func TestRetryGivesUp(t *testing.T) {
+	addr := listenServer(t, func(cmd []string) string {
+		return hangUp
+	})
+	c, err := Dial("tcp", addr, DialRetry(RetryPolicy{MaxAttempts: 2}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	if _, err := c.String("get", "k"); err == nil {
+		t.Fatal("String: expected error after MaxAttempts")
+	}
+}
+
+func TestRetryCanceledWhileDialing(t *testing.T) {
+	var mu sync.Mutex
+	auths := 0
+	addr := listenServer(t, func(cmd []string) string {
+		mu.Lock()
+		defer mu.Unlock()
+		switch {
+		case cmd[0] == "auth":
+			auths++
+			if auths > 1 {
+				return "" // the setup of the new connection never finishes
+			}
+			return "+OK\r\n"
+		case cmd[0] == "get":
+			return hangUp
+		}
+		return "+PONG\r\n"
+	})
+	c, err := Dial("tcp", addr, DialPassword("p"), DialRetry(RetryPolicy{MaxAttempts: 3}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	redial := c.redial
+	c.redial = func(ctx context.Context) (net.Conn, error) {
+		time.Sleep(50 * time.Millisecond) // ignores ctx
+		return redial(context.Background())
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(20*time.Millisecond, cancel)
+	done := make(chan error, 1)
+	go func() {
+		_, err := StringContext(ctx, c, "get", "k")
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err != context.Canceled {
+			t.Errorf("StringContext: expected context.Canceled, got %#v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("StringContext: setup of the new connection ignored the canceled context")
+	}
+}
@@ -0,0 +1,99 @@
+package redisb
+
+import (
+	"errors"
+	"io"
+)
+
+// ErrStreamOpen is returned by calls on a Conn whose RawStream reader has
+// not yet been drained or closed.
+var ErrStreamOpen = errors.New("Bulk String stream from RawStream is still open")
+
+// RawStream sends a command and returns a reader over the payload of its
+// Bulk String reply, read straight off the connection instead of being
+// buffered. A Null reply returns a nil reader. The Conn can not be used for
+// anything else until the reader has returned io.EOF or been closed. The
+// size of the payload is checked against the DecoderOptions of the Conn
+// before any of it is read.
+//
+// Replies of any other type are read in full and reported as a
+// ConversionError, or as the RedisError they carry.
+func (c *Conn) RawStream(args ...string) (io.ReadCloser, error) {
+	if err := c.send(args); err != nil {
+		return nil, err
+	}
+	c.setDeadline(c.readTimeout, deadliner.SetReadDeadline)
+	t, err := c.r.Peek(1)
+	if err != nil {
+		return nil, c.fail(newReaderError("Failed to get Redis type byte in call to Peek: %w", err))
+	}
+	if t[0] != '$' {
+		i, err := c.receive(false)
+		if err != nil {
+			return nil, err
+		}
+		return nil, newConversionError("Conversion to Bulk String stream failed: %#v", i)
+	}
+	c.r.Discard(1)
+	d := c.decoder(false)
+	tmp, err := d.readString()
+	if err != nil {
+		return nil, c.fail(readError("Failed to get raw int for Bulk String size in call to ReadString: %w", err))
+	}
+	if isNegativeOne(tmp) {
+		return nil, nil
+	}
+	slen, err := toUint(tmp)
+	if err != nil {
+		return nil, c.fail(newConversionError("Failed to convert raw int to int for Bulk String size: %s", err))
+	}
+	if err := d.checkBulk(slen); err != nil {
+		return nil, c.fail(err)
+	}
+	c.stream = &bulkReader{c: c, n: slen}
+	return c.stream, nil
+}
+
+type bulkReader struct {
+	c *Conn
+	n uint64
+}
+
+func (br *bulkReader) Read(p []byte) (int, error) {
+	if br.c.stream != br {
+		return 0, io.EOF
+	}
+	if br.n == 0 {
+		return 0, br.finish()
+	}
+	if uint64(len(p)) > br.n {
+		p = p[:br.n]
+	}
+	br.c.setDeadline(br.c.readTimeout, deadliner.SetReadDeadline)
+	n, err := br.c.r.Read(p)
+	br.n -= uint64(n)
+	if err != nil {
+		br.c.stream = nil
+		return n, br.c.fail(newReaderError("Failed to read Bulk String stream: %w", err))
+	}
+	br.c.touch()
+	return n, nil
+}
+
+// Close discards whatever is left of the payload so the Conn can be used
+// again.
+func (br *bulkReader) Close() error {
+	if br.c.stream != br {
+		return nil
+	}
+	_, err := io.Copy(io.Discard, br)
+	return err
+}
+
+func (br *bulkReader) finish() error {
+	br.c.stream = nil
+	if err := br.c.decoder(false).readTerminator(); err != nil {
+		return br.c.fail(err)
+	}
+	return io.EOF
+}
@@ -0,0 +1,62 @@
+package redisb
+
+import (
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestRawStream(t *testing.T) {
+	value := strings.Repeat("0123456789", 1000)
+	c := fakeServer(t, func(cmd []string) string {
+		switch cmd[0] {
+		case "get":
+			if cmd[1] == "missing" {
+				return "$-1\r\n"
+			}
+			return "$" + "10000\r\n" + value + "\r\n"
+		case "incr":
+			return ":7\r\n"
+		}
+		return "-ERR unknown command\r\n"
+	})
+	r, err := c.RawStream("get", "big")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Int64("incr", "a"); err != ErrStreamOpen {
+		t.Errorf("Int64: expected ErrStreamOpen, got %#v", err)
+	}
+	var b strings.Builder
+	if _, err := io.Copy(&b, r); err != nil {
+		t.Fatal(err)
+	}
+	if b.String() != value {
+		t.Errorf("RawStream: read %d bytes, want %d", b.Len(), len(value))
+	}
+	if i, err := c.Int64("incr", "a"); err != nil || i != 7 {
+		t.Errorf("Int64 after drain: %d, %v", i, err)
+	}
+
+	r, err = c.RawStream("get", "big")
+	if err != nil {
+		t.Fatal(err)
+	}
+	io.ReadFull(r, make([]byte, 10))
+	if err := r.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if i, err := c.Int64("incr", "a"); err != nil || i != 7 {
+		t.Errorf("Int64 after Close: %d, %v", i, err)
+	}
+
+	if r, err := c.RawStream("get", "missing"); r != nil || err != nil {
+		t.Errorf("RawStream Null: %v, %v", r, err)
+	}
+	if _, err := c.RawStream("incr", "a"); err == nil {
+		t.Error("RawStream: expected ConversionError for Integer reply")
+	}
+	if c.Err() != nil {
+		t.Errorf("Err: %s", c.Err())
+	}
+}
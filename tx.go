@@ -0,0 +1,190 @@
+package redisb
+
+import (
+	"context"
+	"errors"
+)
+
+var (
+	// ErrTxAborted is returned by Tx.Exec when the server discarded the
+	// transaction with EXECABORT because it rejected one of its commands. The
+	// results of the rejected commands hold the reason.
+	ErrTxAborted = errors.New("Transaction aborted because a command was rejected")
+	// ErrTxConflict is returned by Tx.Exec when EXEC returned nil because a
+	// WATCHed key changed.
+	ErrTxConflict = errors.New("Transaction aborted because a watched key changed")
+)
+
+// Tx queues commands to run on a Conn as a MULTI/EXEC transaction, each
+// returning a result to read after Exec.
+//
+//	tx := c.Tx()
+//	n := tx.Int64("incr", "hits")
+//	tx.Bool("expire", "hits", "60")
+//	err := tx.Exec()
+//	hits, err := n.Result()
+type Tx struct {
+	queue
+	c *Conn
+}
+
+func (c *Conn) Tx() *Tx {
+	return &Tx{c: c}
+}
+
+// Exec sends MULTI, the queued commands and EXEC in a single write, checks
+// that every command was QUEUED, and fills in the results from the reply to
+// EXEC. A command that failed while the transaction ran gets its RedisError
+// without affecting the others. A command answered with anything but QUEUED
+// gets that error reply, or a ConversionError, and leaves the Conn usable.
+//
+// If the transaction did not run, Exec returns ErrTxAborted or ErrTxConflict
+// and every result gets that error, except for those of commands the server
+// rejected, which get their RedisError. Any other error from Exec either
+// left the Conn unusable, or is an argument that could not be encoded, in
+// which case nothing was sent.
+//
+// The Tx is empty again afterwards.
+func (tx *Tx) Exec() error {
+	return tx.c.execTx(tx.take())
+}
+
+// ExecContext is Exec with the deadline and cancellation of ctx applied.
+func (tx *Tx) ExecContext(ctx context.Context) error {
+	results := tx.take()
+	return tx.c.withContext(ctx, func() error {
+		return tx.c.execTx(results)
+	})
+}
+
+func (c *Conn) execTx(results []*result) error {
+	err := c.ready()
+	if err == nil {
+		err = c.appendTx(results)
+	}
+	if err == nil {
+		err = c.flush()
+	}
+	var replies []Reply
+	for i := 0; err == nil && i < len(results)+2; i++ {
+		var r Reply
+		r, err = c.receiveReply()
+		replies = append(replies, r)
+	}
+	if err != nil {
+		setAll(results, err)
+		return err
+	}
+	if err := replies[0].Err(); err != nil {
+		setAll(results, err)
+		return err
+	}
+	// a command the server did not queue gets the reply it got instead, and
+	// has no place among the replies to EXEC
+	var queued []*result
+	for i, r := range results {
+		reply := replies[i+1]
+		if err := reply.Err(); err != nil {
+			r.set(Reply{}, err)
+			continue
+		}
+		if s, _ := reply.Str(); s != "QUEUED" {
+			r.set(Reply{}, newConversionError("Unexpected reply to queued command: %#v", reply))
+			continue
+		}
+		queued = append(queued, r)
+	}
+	exec := replies[len(replies)-1]
+	switch {
+	case exec.IsNil():
+		err = ErrTxConflict
+	case exec.Kind == KindError:
+		err = exec.Err()
+		if re, ok := err.(RedisError); ok && re.Prefix == "EXECABORT" {
+			err = ErrTxAborted
+		}
+	case len(exec.Elems()) != len(queued):
+		err = c.fail(newConversionError("EXEC returned %d replies for %d commands", len(exec.Elems()), len(queued)))
+		setAll(results, err)
+		return err
+	default:
+		for i, r := range queued {
+			r.set(exec.Elems()[i], nil)
+		}
+		return nil
+	}
+	for _, r := range results {
+		if !r.done {
+			r.set(Reply{}, err)
+		}
+	}
+	return err
+}
+
+// appendTx writes the whole transaction to the Writer of c, or nothing if
+// one of the commands can not be encoded.
+func (c *Conn) appendTx(results []*result) error {
+	mark := len(c.w.buf)
+	c.w.appendArgs([]string{"multi"})
+	for _, r := range results {
+		if err := c.w.appendArgs(r.args); err != nil {
+			c.w.buf = c.w.buf[:mark]
+			return err
+		}
+	}
+	c.w.appendArgs([]string{"exec"})
+	for _, r := range results {
+		c.note(r.args)
+	}
+	c.note([]string{"exec"})
+	return nil
+}
+
+func setAll(results []*result, err error) {
+	for _, r := range results {
+		r.set(Reply{}, err)
+	}
+}
+
+// Transaction runs a check-and-set transaction on c. It WATCHes keys and
+// calls fn, which can read them through c and queues the writes on tx, then
+// runs tx. When a watched key changed before EXEC, or c reconnected since the
+// WATCH, which leaves the keys unwatched, it starts over, up to maxRetries
+// times before giving up with ErrTxConflict.
+//
+// If fn returns an error or panics, the keys are UNWATCHed and the queued
+// commands are never sent. Since Tx sends MULTI only along with EXEC, there
+// is never a transaction left to DISCARD.
+func Transaction(c *Conn, keys []string, fn func(tx *Tx) error, maxRetries int) error {
+	for attempt := 0; ; attempt++ {
+		err := transaction(c, keys, fn)
+		if err != ErrTxConflict || attempt >= maxRetries {
+			return err
+		}
+	}
+}
+
+func transaction(c *Conn, keys []string, fn func(tx *Tx) error) error {
+	if _, err := Watch(c, keys...); err != nil {
+		return err
+	}
+	gen := c.gen
+	executed := false
+	defer func() {
+		if !executed && c.Err() == nil {
+			Unwatch(c)
+		}
+	}()
+	tx := c.Tx()
+	if err := fn(tx); err != nil {
+		return err
+	}
+	if tx.Len() == 0 {
+		return nil
+	}
+	if c.gen != gen {
+		return ErrTxConflict
+	}
+	executed = true
+	return tx.Exec()
+}
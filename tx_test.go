@@ -0,0 +1,227 @@
+package redisb
+
+import (
+	"errors"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func txServer(t *testing.T, exec string) *Conn {
+	multi := false
+	return fakeServer(t, func(cmd []string) string {
+		switch {
+		case cmd[0] == "multi":
+			multi = true
+			return "+OK\r\n"
+		case cmd[0] == "exec":
+			multi = false
+			return exec
+		case cmd[0] == "bad":
+			return "-ERR unknown command 'bad'\r\n"
+		case cmd[0] == "odd":
+			return "+OK\r\n"
+		case multi:
+			return "+QUEUED\r\n"
+		}
+		return "+OK\r\n"
+	})
+}
+
+func TestTx(t *testing.T) {
+	c := txServer(t, "*3\r\n:1\r\n-WRONGTYPE Operation against a key holding the wrong kind of value\r\n$1\r\nv\r\n")
+	tx := c.Tx()
+	n := tx.Int64("incr", "k")
+	wrong := tx.Int64("incr", "list")
+	s := tx.String("get", "s")
+	if err := tx.Exec(); err != nil {
+		t.Fatal(err)
+	}
+	if i, err := n.Result(); err != nil || i != 1 {
+		t.Errorf("incr: %d, %v", i, err)
+	}
+	if re, ok := wrong.Err().(RedisError); !ok || re.Prefix != "WRONGTYPE" {
+		t.Errorf("incr list: %#v", wrong.Err())
+	}
+	if v, err := s.Result(); err != nil || v != "v" {
+		t.Errorf("get: %q, %v", v, err)
+	}
+	if ok, err := c.Bool("set", "k", "v"); err != nil || !ok {
+		t.Errorf("set after Exec: %v, %v", ok, err)
+	}
+}
+
+func TestTxAborted(t *testing.T) {
+	tests := []struct {
+		exec string
+		bad  bool
+		want error
+	}{
+		{"-EXECABORT Transaction discarded because of previous errors.\r\n", true, ErrTxAborted},
+		{"*-1\r\n", false, ErrTxConflict},
+		{"_\r\n", false, ErrTxConflict},
+	}
+	for _, test := range tests {
+		c := txServer(t, test.exec)
+		tx := c.Tx()
+		ok := tx.Bool("set", "k", "v")
+		if test.bad {
+			tx.Raw("bad")
+		}
+		results := tx.results
+		if err := tx.Exec(); err != test.want {
+			t.Errorf("Exec %q: %v", test.exec, err)
+		}
+		if ok.Err() != test.want {
+			t.Errorf("Exec %q: set: %v", test.exec, ok.Err())
+		}
+		if test.bad {
+			if re, isRedis := results[1].err.(RedisError); !isRedis || !strings.Contains(re.Suffix, "unknown command") {
+				t.Errorf("Exec %q: bad: %#v", test.exec, results[1].err)
+			}
+		}
+		if c.Err() != nil {
+			t.Errorf("Exec %q: Conn left unusable: %v", test.exec, c.Err())
+		}
+	}
+}
+
+func TestTxNotQueued(t *testing.T) {
+	c := txServer(t, "-EXECABORT Transaction discarded because of previous errors.\r\n")
+	tx := c.Tx()
+	ok := tx.Bool("set", "k", "v")
+	odd := tx.Raw("odd")
+	if err := tx.Exec(); err != ErrTxAborted {
+		t.Errorf("Exec: %v", err)
+	}
+	if ok.Err() != ErrTxAborted {
+		t.Errorf("set: %v", ok.Err())
+	}
+	if _, isConversion := odd.Err().(ConversionError); !isConversion {
+		t.Errorf("odd: %#v", odd.Err())
+	}
+	if s, err := c.String("ping"); err != nil || s != "OK" {
+		t.Errorf("ping after Exec: %q, %v", s, err)
+	}
+}
+
+func TestTransaction(t *testing.T) {
+	var sent []string
+	conflicts := 2
+	multi := false
+	c := fakeServer(t, func(cmd []string) string {
+		sent = append(sent, cmd[0])
+		switch {
+		case cmd[0] == "multi":
+			multi = true
+			return "+OK\r\n"
+		case cmd[0] == "exec":
+			multi = false
+			if conflicts > 0 {
+				conflicts--
+				return "*-1\r\n"
+			}
+			return "*1\r\n+OK\r\n"
+		case multi:
+			return "+QUEUED\r\n"
+		case cmd[0] == "get":
+			return "$1\r\n1\r\n"
+		}
+		return "+OK\r\n"
+	})
+	calls := 0
+	set := func(tx *Tx) error {
+		calls++
+		n, err := c.Int64("get", "k")
+		if err != nil {
+			return err
+		}
+		tx.Bool("set", "k", strconv.FormatInt(n+1, 10))
+		return nil
+	}
+	if err := Transaction(c, []string{"k"}, set, 1); err != ErrTxConflict {
+		t.Fatalf("Transaction with 1 retry: %v", err)
+	}
+	if err := Transaction(c, []string{"k"}, set, 1); err != nil {
+		t.Fatalf("Transaction: %v", err)
+	}
+	if calls != 3 {
+		t.Errorf("fn called %d times", calls)
+	}
+
+	sent = nil
+	failed := errors.New("failed")
+	if err := Transaction(c, []string{"k"}, func(tx *Tx) error {
+		tx.Bool("set", "k", "v")
+		return failed
+	}, 3); err != failed {
+		t.Errorf("Transaction with failing fn: %v", err)
+	}
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("Transaction swallowed panic")
+			}
+		}()
+		Transaction(c, []string{"k"}, func(tx *Tx) error { panic("boom") }, 3)
+	}()
+	want := []string{"watch", "unwatch", "watch", "unwatch"}
+	if !reflect.DeepEqual(sent, want) {
+		t.Errorf("sent: %q", sent)
+	}
+}
+
+func TestTransactionReconnect(t *testing.T) {
+	var mu sync.Mutex
+	var sent []string
+	gets := 0
+	multi := false
+	addr := listenServer(t, func(cmd []string) string {
+		mu.Lock()
+		defer mu.Unlock()
+		sent = append(sent, cmd[0])
+		switch {
+		case cmd[0] == "get":
+			gets++
+			if gets == 1 {
+				return hangUp
+			}
+			return "$1\r\n1\r\n"
+		case cmd[0] == "multi":
+			multi = true
+			return "+OK\r\n"
+		case cmd[0] == "exec":
+			multi = false
+			return "*1\r\n+OK\r\n"
+		case multi:
+			return "+QUEUED\r\n"
+		}
+		return "+OK\r\n"
+	})
+	c, err := Dial("tcp", addr, DialRetry(RetryPolicy{MaxAttempts: 2}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	set := func(tx *Tx) error {
+		if _, err := c.Int64("get", "k"); err != nil {
+			return err
+		}
+		tx.Bool("set", "k", "2")
+		return nil
+	}
+	if err := Transaction(c, []string{"k"}, set, 0); err != ErrTxConflict {
+		t.Errorf("Transaction across reconnect: %v", err)
+	}
+	if err := Transaction(c, []string{"k"}, set, 0); err != nil {
+		t.Errorf("Transaction: %v", err)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	want := []string{"watch", "get", "get", "unwatch", "watch", "get", "multi", "set", "exec"}
+	if !reflect.DeepEqual(sent, want) {
+		t.Errorf("sent: %q", sent)
+	}
+}
@@ -0,0 +1,169 @@
+package redisb
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// URLConfig is a connection configuration read by ParseURL.
+type URLConfig struct {
+	Network string
+	Address string
+	Options []Option
+
+	MaxIdle         int
+	MaxActive       int
+	IdleTimeout     time.Duration
+	MaxConnLifetime time.Duration
+	Wait            bool
+}
+
+// Dial connects as configured by uc.
+func (uc URLConfig) Dial() (*Conn, error) {
+	return Dial(uc.Network, uc.Address, uc.Options...)
+}
+
+// Pool returns a Pool of connections configured by uc.
+func (uc URLConfig) Pool() *Pool {
+	return &Pool{
+		Dial:            uc.Dial,
+		MaxIdle:         uc.MaxIdle,
+		MaxActive:       uc.MaxActive,
+		IdleTimeout:     uc.IdleTimeout,
+		MaxConnLifetime: uc.MaxConnLifetime,
+		Wait:            uc.Wait,
+	}
+}
+
+// ParseURL reads a URL of the form
+//
+//	redis://[[user]:password@]host[:port][/db][?param=value&...]
+//	rediss://...
+//	unix://[[user]:password@]/path/to/socket[?db=n&param=value&...]
+//
+// rediss connects with TLS. A user given without a password, or with an
+// empty one, is an error, since it could be meant as either. So is a
+// database given both as the path and as the db parameter. The query parameters are dial_timeout,
+// read_timeout, write_timeout, protocol, client_name and db, along with
+// max_idle, max_active, idle_timeout, max_conn_lifetime and wait for Pool.
+// Any other parameter is an error.
+func ParseURL(rawurl string) (URLConfig, error) {
+	u, err := url.Parse(rawurl)
+	if err != nil {
+		// url.Error repeats the URL, password included
+		if ue, ok := err.(*url.Error); ok {
+			err = ue.Err
+		}
+		return URLConfig{}, fmt.Errorf("Invalid Redis URL: %s", err)
+	}
+	var uc URLConfig
+	switch u.Scheme {
+	case "redis", "rediss":
+		uc.Network = "tcp"
+		host, port := u.Hostname(), u.Port()
+		if host == "" {
+			host = "localhost"
+		}
+		if port == "" {
+			port = "6379"
+		}
+		uc.Address = net.JoinHostPort(host, port)
+		if u.Scheme == "rediss" {
+			uc.Options = append(uc.Options, DialTLSConfig(&tls.Config{MinVersion: tls.VersionTLS12}))
+		}
+		if db := strings.TrimPrefix(u.Path, "/"); db != "" {
+			n, err := strconv.Atoi(db)
+			if err != nil {
+				return URLConfig{}, fmt.Errorf("Invalid database %q in Redis URL", db)
+			}
+			uc.Options = append(uc.Options, DialDB(n))
+			if u.Query().Has("db") {
+				return URLConfig{}, fmt.Errorf("Database set both by path and by db parameter in Redis URL")
+			}
+		}
+	case "unix":
+		uc.Network = "unix"
+		uc.Address = u.Path
+		if uc.Address == "" {
+			return URLConfig{}, fmt.Errorf("Missing socket path in Redis URL %q", u.Redacted())
+		}
+	default:
+		return URLConfig{}, fmt.Errorf("Unsupported Redis URL scheme %q", u.Scheme)
+	}
+	if u.User != nil {
+		password, _ := u.User.Password()
+		if password == "" {
+			return URLConfig{}, fmt.Errorf("Missing password for user %q in Redis URL", u.User.Username())
+		}
+		if u.User.Username() != "" {
+			uc.Options = append(uc.Options, DialUsername(u.User.Username()))
+		}
+		uc.Options = append(uc.Options, DialPassword(password))
+	}
+	for k, vs := range u.Query() {
+		v := vs[len(vs)-1]
+		if !urlParams[k] {
+			return URLConfig{}, fmt.Errorf("Unknown parameter %q in Redis URL", k)
+		}
+		if err := uc.setParam(k, v); err != nil {
+			return URLConfig{}, fmt.Errorf("Invalid value %q for %s in Redis URL: %s", v, k, err)
+		}
+	}
+	return uc, nil
+}
+
+var urlParams = map[string]bool{
+	"dial_timeout": true, "read_timeout": true, "write_timeout": true,
+	"protocol": true, "client_name": true, "db": true,
+	"max_idle": true, "max_active": true, "idle_timeout": true, "max_conn_lifetime": true, "wait": true,
+}
+
+func (uc *URLConfig) setParam(k, v string) error {
+	var err error
+	var d time.Duration
+	var n int
+	switch k {
+	case "dial_timeout", "read_timeout", "write_timeout", "idle_timeout", "max_conn_lifetime":
+		d, err = time.ParseDuration(v)
+	case "protocol", "db", "max_idle", "max_active":
+		n, err = strconv.Atoi(v)
+	case "wait":
+		uc.Wait, err = strconv.ParseBool(v)
+	case "client_name":
+		uc.Options = append(uc.Options, DialClientName(v))
+	}
+	if err != nil {
+		return err
+	}
+	switch k {
+	case "dial_timeout":
+		uc.Options = append(uc.Options, DialTimeout(d))
+	case "read_timeout":
+		uc.Options = append(uc.Options, DialReadTimeout(d))
+	case "write_timeout":
+		uc.Options = append(uc.Options, DialWriteTimeout(d))
+	case "idle_timeout":
+		uc.IdleTimeout = d
+	case "max_conn_lifetime":
+		uc.MaxConnLifetime = d
+	case "protocol":
+		if n != 2 && n != 3 {
+			return fmt.Errorf("protocol must be 2 or 3")
+		}
+		if n == 3 {
+			uc.Options = append(uc.Options, DialHello(n, HelloOptions{}))
+		}
+	case "db":
+		uc.Options = append(uc.Options, DialDB(n))
+	case "max_idle":
+		uc.MaxIdle = n
+	case "max_active":
+		uc.MaxActive = n
+	}
+	return nil
+}
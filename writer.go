@@ -0,0 +1,196 @@
+package redisb
+
+import (
+	"encoding"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"time"
+)
+
+// Writer encodes commands as RESP arrays of Bulk Strings directly into a
+// reusable buffer, and writes that buffer to the underlying io.Writer on Flush.
+type Writer struct {
+	w       io.Writer
+	buf     []byte
+	scratch []byte
+}
+
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{w: w}
+}
+
+// WriteCommand appends a single command and flushes it.
+func (w *Writer) WriteCommand(args ...interface{}) error {
+	if err := w.Append(args...); err != nil {
+		return err
+	}
+	return w.Flush()
+}
+
+// Append encodes a command into the buffer without writing it. Nothing is
+// appended if any argument has an unsupported type.
+func (w *Writer) Append(args ...interface{}) error {
+	mark := len(w.buf)
+	w.appendHeader('*', len(args))
+	for i, v := range args {
+		if err := w.appendArg(i, v); err != nil {
+			w.buf = w.buf[:mark]
+			return err
+		}
+	}
+	return nil
+}
+
+// Reset discards anything buffered and makes w write to dst.
+func (w *Writer) Reset(dst io.Writer) {
+	w.w = dst
+	w.buf = w.buf[:0]
+}
+
+// Buffered returns the number of bytes waiting for Flush.
+func (w *Writer) Buffered() int {
+	return len(w.buf)
+}
+
+func (w *Writer) Flush() error {
+	if len(w.buf) == 0 {
+		return nil
+	}
+	_, err := w.w.Write(w.buf)
+	w.buf = w.buf[:0]
+	if err != nil {
+		return newWriterError("Failed to write command: %w", err)
+	}
+	return nil
+}
+
+func (w *Writer) appendArgs(args interface{}) error {
+	switch t := args.(type) {
+	case []string:
+		w.appendHeader('*', len(t))
+		for _, v := range t {
+			w.appendBulk(v)
+		}
+	case [][]byte:
+		w.appendHeader('*', len(t))
+		for _, v := range t {
+			w.appendBulkBytes(v)
+		}
+	case []interface{}:
+		return w.Append(t...)
+	case Args:
+		return w.Append(t...)
+	default:
+		return newProtocolError(0, 0, "Unable to write command of type: %T", args)
+	}
+	return nil
+}
+
+// appendArg encodes the i'th argument v. Integers of any size are written in
+// decimal, floats as Redis formats them (see appendFloat), bools as 1 or 0,
+// a time.Duration as whole milliseconds, at least 1 unless it is zero, and a
+// time.Time as Unix milliseconds, both as taken by PX, PEXPIRE and PEXPIREAT.
+func (w *Writer) appendArg(i int, v interface{}) error {
+	switch t := v.(type) {
+	case string:
+		w.appendBulk(t)
+	case []byte:
+		w.appendBulkBytes(t)
+	case int:
+		w.appendInt(int64(t))
+	case int8:
+		w.appendInt(int64(t))
+	case int16:
+		w.appendInt(int64(t))
+	case int32:
+		w.appendInt(int64(t))
+	case int64:
+		w.appendInt(t)
+	case uint:
+		w.appendUint(uint64(t))
+	case uint8:
+		w.appendUint(uint64(t))
+	case uint16:
+		w.appendUint(uint64(t))
+	case uint32:
+		w.appendUint(uint64(t))
+	case uint64:
+		w.appendUint(t)
+	case float32:
+		return w.appendFloat(i, float64(t), 32)
+	case float64:
+		return w.appendFloat(i, t, 64)
+	case bool:
+		if t {
+			w.appendBulk("1")
+		} else {
+			w.appendBulk("0")
+		}
+	case time.Duration:
+		ms := int64(t / time.Millisecond)
+		if ms == 0 && t > 0 {
+			ms = 1
+		}
+		w.appendInt(ms)
+	case time.Time:
+		w.appendInt(t.UnixNano() / int64(time.Millisecond))
+	case encoding.BinaryMarshaler:
+		b, err := t.MarshalBinary()
+		if err != nil {
+			return newProtocolError(0, int64(i), "Unable to marshal argument of type %T: %s", v, err)
+		}
+		w.appendBulkBytes(b)
+	case fmt.Stringer:
+		w.appendBulk(t.String())
+	default:
+		return newProtocolError(0, int64(i), "Unable to write argument of type: %T", v)
+	}
+	return nil
+}
+
+func (w *Writer) appendInt(n int64) {
+	w.scratch = strconv.AppendInt(w.scratch[:0], n, 10)
+	w.appendBulkBytes(w.scratch)
+}
+
+func (w *Writer) appendUint(n uint64) {
+	w.scratch = strconv.AppendUint(w.scratch[:0], n, 10)
+	w.appendBulkBytes(w.scratch)
+}
+
+// appendFloat writes f without an exponent, so 1e6 is sent as "1000000",
+// and infinities as the "inf" and "-inf" Redis accepts for scores.
+func (w *Writer) appendFloat(i int, f float64, bitSize int) error {
+	switch {
+	case math.IsNaN(f):
+		return newProtocolError(0, int64(i), "Unable to write NaN argument")
+	case math.IsInf(f, 1):
+		w.appendBulk("inf")
+	case math.IsInf(f, -1):
+		w.appendBulk("-inf")
+	default:
+		w.scratch = strconv.AppendFloat(w.scratch[:0], f, 'f', -1, bitSize)
+		w.appendBulkBytes(w.scratch)
+	}
+	return nil
+}
+
+func (w *Writer) appendHeader(prefix byte, n int) {
+	w.buf = append(w.buf, prefix)
+	w.buf = strconv.AppendInt(w.buf, int64(n), 10)
+	w.buf = append(w.buf, '\r', '\n')
+}
+
+func (w *Writer) appendBulk(s string) {
+	w.appendHeader('$', len(s))
+	w.buf = append(w.buf, s...)
+	w.buf = append(w.buf, '\r', '\n')
+}
+
+func (w *Writer) appendBulkBytes(b []byte) {
+	w.appendHeader('$', len(b))
+	w.buf = append(w.buf, b...)
+	w.buf = append(w.buf, '\r', '\n')
+}
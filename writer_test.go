@@ -0,0 +1,48 @@
+package redisb
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+)
+
+type failingWriter struct{}
+
+func (failingWriter) Write(p []byte) (int, error) { return 0, errors.New("closed") }
+
+func TestWriter(t *testing.T) {
+	var b bytes.Buffer
+	w := NewWriter(&b)
+	if err := w.WriteCommand("set", []byte("k"), int64(-12), 1.5, 3); err != nil {
+		t.Fatal(err)
+	}
+	want := "*5\r\n$3\r\nset\r\n$1\r\nk\r\n$3\r\n-12\r\n$3\r\n1.5\r\n$1\r\n3\r\n"
+	if b.String() != want {
+		t.Errorf("WriteCommand: %q - %q", want, b.String())
+	}
+	b.Reset()
+	w.Append("get", "a")
+	if err, ok := w.Append("get", struct{}{}).(ProtocolError); !ok || err.Offset != 1 {
+		t.Errorf("Append: expected ProtocolError for unsupported type, got %#v", err)
+	}
+	w.Flush()
+	if b.String() != Encode([]string{"get", "a"}) {
+		t.Errorf("Append kept a partial command: %q", b.String())
+	}
+	err := NewWriter(failingWriter{}).WriteCommand("ping")
+	if _, ok := err.(WriterError); !ok {
+		t.Errorf("WriteCommand: expected WriterError, got %#v", err)
+	}
+}
+
+func TestWriterAllocs(t *testing.T) {
+	w := NewWriter(io.Discard)
+	w.WriteCommand("set", "key", "value")
+	allocs := testing.AllocsPerRun(100, func() {
+		w.WriteCommand("set", "key", "value")
+	})
+	if allocs != 0 {
+		t.Errorf("WriteCommand allocated %v times per run", allocs)
+	}
+}